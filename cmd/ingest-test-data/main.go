@@ -2,14 +2,25 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 )
 
+// supportedExtensions are the file types accepted by /ingest/file
+var supportedExtensions = map[string]bool{
+	".txt":  true,
+	".md":   true,
+	".html": true,
+	".htm":  true,
+	".json": true,
+	".csv":  true,
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		slog.Error("Usage: go run scripts/ingest_testdata.go <server-url>")
@@ -19,59 +30,74 @@ func main() {
 	serverURL := os.Args[1]
 	testDataDir := "testdata/docs"
 
-	// Read all .txt files from testdata/docs
-	files, err := filepath.Glob(filepath.Join(testDataDir, "*.txt"))
+	// Collect all supported files from testdata/docs
+	entries, err := os.ReadDir(testDataDir)
 	if err != nil {
 		slog.Error("Failed to read testdata directory", "error", err)
 		os.Exit(1)
 	}
 
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && supportedExtensions[filepath.Ext(entry.Name())] {
+			files = append(files, filepath.Join(testDataDir, entry.Name()))
+		}
+	}
+
 	if len(files) == 0 {
-		slog.Error("No .txt files found in testdata/docs")
+		slog.Error("No supported files found in testdata/docs")
 		os.Exit(1)
 	}
 
-	// Ingest each file
+	// Ingest each file, the server detects the format and uses the file name as document ID
 	for _, file := range files {
-		content, err := os.ReadFile(file)
-		if err != nil {
-			slog.Error("Failed to read file", "file", file, "error", err)
+		if err := uploadFile(serverURL+"/ingest/file", file); err != nil {
+			slog.Error("Failed to ingest file", "file", file, "error", err)
 			continue
 		}
 
-		// Prepare request
-		reqBody := map[string]interface{}{
-			"text": string(content),
-			"id":   filepath.Base(file),
-		}
+		slog.Info("Successfully ingested file", "file", file)
+	}
 
-		jsonData, err := json.Marshal(reqBody)
-		if err != nil {
-			slog.Error("Failed to marshal request", "file", file, "error", err)
-			continue
-		}
-		url := fmt.Sprintf("%s/ingest", serverURL)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			slog.Error("Failed to create request", "file", file, "error", err)
-			continue
-		}
-		req.Header.Set("Content-Type", "application/json")
+	slog.Info("Ingestion complete!")
+}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			slog.Error("Failed to ingest file", "file", file, "error", err)
-			continue
-		}
-		defer resp.Body.Close()
+// uploadFile posts a file as multipart form data
+func uploadFile(url, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			slog.Error("Failed to ingest file", "file", file, "status", resp.StatusCode)
-			continue
-		}
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return fmt.Errorf("failed to write form file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart body: %w", err)
+	}
 
-		slog.Info("Successfully ingested file", "file", file)
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	slog.Info("Ingestion complete!")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	return nil
 }
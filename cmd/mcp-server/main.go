@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/app"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/config"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/mcp"
+)
+
+func main() {
+	// MCP transport flags, parsed together with the shared configuration
+	transport := flag.String("transport", envOr("MCP_TRANSPORT", "stdio"), "MCP transport: stdio or http")
+	addr := flag.String("mcp-addr", envOr("MCP_ADDR", "127.0.0.1:8090"), "Listen address of the http transport")
+
+	// Logs go to stderr, stdout carries the stdio transport
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		slog.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	// Initialize LLM client and RAG pipeline
+	llmClient, err := app.NewLLMClient(cfg)
+	if err != nil {
+		slog.Error("Failed to create LLM client", "error", err)
+		os.Exit(1)
+	}
+
+	pipeline, err := app.NewPipeline(ctx, cfg, llmClient)
+	if err != nil {
+		slog.Error("Failed to create RAG pipeline", "error", err)
+		os.Exit(1)
+	}
+
+	server := mcp.NewServer(pipeline, llmClient)
+
+	switch *transport {
+	case "stdio":
+		// The client ends the session by closing stdin
+		slog.Info("MCP server running on stdio")
+		if err := server.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil {
+			slog.Error("MCP server failed", "error", err)
+			os.Exit(1)
+		}
+	case "http":
+		serveHTTP(server, *addr)
+	default:
+		slog.Error("Unknown MCP transport (expected stdio or http)", "transport", *transport)
+		os.Exit(1)
+	}
+}
+
+// serveHTTP serves the streamable HTTP transport at /mcp until the process is interrupted
+func serveHTTP(server *mcp.Server, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", server)
+
+	httpServer := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		slog.Info("MCP server running", "addr", addr, "path", "/mcp")
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("MCP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	slog.Info("Shutting down MCP server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		slog.Error("MCP server forced to shutdown", "error", err)
+		os.Exit(1)
+	}
+}
+
+// envOr returns an environment variable or a default value
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -9,9 +9,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/app"
 	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/config"
-	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/llm"
-	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/conversation"
 
 	httphandler "github.com/vokinneberg/ya-practicum-go-and-llm/internal/http"
 )
@@ -24,32 +24,38 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Initialize LLM client
-	llmClient := llm.NewClient(cfg.OpenAIAPIKey, cfg.OpenAIModel, cfg.OpenAIEmbedModel)
-	slog.Info("Initialized OpenAI client")
-
-	// Initialize Qdrant client
-	qdrantClient, err := rag.NewQdrantClient(cfg.QdrantHost, cfg.QdrantPort, cfg.QdrantCollection)
+	// Initialize LLM client and RAG pipeline
+	llmClient, err := app.NewLLMClient(cfg)
 	if err != nil {
-		slog.Error("Failed to create Qdrant client", "error", err)
+		slog.Error("Failed to create LLM client", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("Initialized Qdrant client")
-
-	// Initialize chunker
-	chunker := rag.NewChunker(cfg.ChunkSize, cfg.ChunkOverlap)
-	slog.Info("Initialized chunker", "size", cfg.ChunkSize, "overlap", cfg.ChunkOverlap)
 
-	// Initialize RAG pipeline
-	pipeline, err := rag.NewPipeline(chunker, llmClient, qdrantClient, cfg.SearchLimit)
+	pipeline, err := app.NewPipeline(context.Background(), cfg, llmClient)
 	if err != nil {
 		slog.Error("Failed to create RAG pipeline", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("Initialized RAG pipeline")
+
+	// Initialize conversation history store
+	var historyStore httphandler.HistoryStore
+	switch cfg.HistoryStore {
+	case "memory":
+		historyStore = conversation.NewMemoryStore(cfg.HistoryMaxMessages, cfg.HistoryMaxConversations)
+	case "file":
+		fileStore, err := conversation.NewFileStore(cfg.HistoryPath, cfg.HistoryMaxMessages)
+		if err != nil {
+			slog.Error("Failed to create history store", "error", err)
+			os.Exit(1)
+		}
+		historyStore = fileStore
+	}
+	if historyStore != nil {
+		slog.Info("Initialized conversation history store", "type", cfg.HistoryStore, "max_messages", cfg.HistoryMaxMessages, "max_conversations", cfg.HistoryMaxConversations)
+	}
 
 	// Initialize HTTP handlers
-	handler := httphandler.NewHandlers(pipeline, llmClient)
+	handler := httphandler.NewHandlers(pipeline, llmClient, historyStore)
 
 	// Create router
 	r := httphandler.NewRouter(handler)
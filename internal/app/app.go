@@ -0,0 +1,132 @@
+// Package app wires the LLM client and the RAG pipeline from the configuration,
+// so that every entry point serves the same pipeline.
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/config"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/llm"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/tokenizer"
+)
+
+// NewLLMClient creates the LLM client for the configured provider
+func NewLLMClient(cfg *config.Config) (*llm.Client, error) {
+	provider, err := llm.NewProvider(llm.ProviderConfig{
+		Name:    cfg.LLMProvider,
+		APIKey:  cfg.OpenAIAPIKey,
+		BaseURL: cfg.LLMBaseURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	llmClient := llm.NewClient(provider, cfg.LLMProvider, cfg.OpenAIModel, cfg.OpenAIEmbedModel, cfg.EmbedBatchSize, cfg.EmbedDimensions)
+	slog.Info("Initialized LLM client", "provider", cfg.LLMProvider, "model", cfg.OpenAIModel)
+
+	return llmClient, nil
+}
+
+// NewPipeline creates the RAG pipeline on top of the configured vector store and loads its keyword index
+func NewPipeline(ctx context.Context, cfg *config.Config, llmClient *llm.Client) (*rag.Pipeline, error) {
+	// Detect embedding dimension so the collection matches the embedding model
+	embedDimension, err := llmClient.EmbeddingDimension(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect embedding dimension: %w", err)
+	}
+	slog.Info("Detected embedding dimension", "model", cfg.OpenAIEmbedModel, "dimension", embedDimension)
+
+	// Initialize vector store
+	var vectorDB rag.VectorDatabase
+	switch cfg.VectorStore {
+	case "memory":
+		memoryStore, err := rag.NewMemoryStore(cfg.MemoryStorePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create memory store: %w", err)
+		}
+		vectorDB = memoryStore
+		slog.Info("Initialized in-memory vector store", "path", cfg.MemoryStorePath)
+	default:
+		qdrantClient, err := rag.NewQdrantClient(cfg.QdrantHost, cfg.QdrantPort, cfg.QdrantCollection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Qdrant client: %w", err)
+		}
+		vectorDB = qdrantClient
+		slog.Info("Initialized Qdrant client")
+	}
+
+	// Initialize chunker; chunkOverlap stays zero for chunkers that do not repeat text between chunks
+	var chunker rag.TextChunker
+	chunkOverlap := 0
+	switch cfg.Chunker {
+	case "markdown":
+		chunker = rag.NewMarkdownChunker(cfg.ChunkSize)
+	case "recursive":
+		chunker = rag.NewRecursiveChunker(cfg.ChunkSize, cfg.ChunkOverlap)
+		chunkOverlap = cfg.ChunkOverlap
+	case "code":
+		chunker = rag.NewCodeChunker(cfg.ChunkSize, cfg.ChunkOverlap)
+		chunkOverlap = cfg.ChunkOverlap
+	case "semantic":
+		chunker = rag.NewSemanticChunker(llmClient, cfg.SemanticMinSize, cfg.ChunkSize, cfg.SemanticPercentile)
+	case "token":
+		encoding, err := tokenizer.LoadEncoding(cfg.TokenizerEncoding, cfg.TokenizerPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+		}
+		chunker = rag.NewTokenChunker(encoding, cfg.ChunkSize, cfg.ChunkOverlap)
+		chunkOverlap = cfg.ChunkOverlap
+	default:
+		chunker = rag.NewChunker(cfg.ChunkSize, cfg.ChunkOverlap)
+		chunkOverlap = cfg.ChunkOverlap
+	}
+	slog.Info("Initialized chunker", "type", cfg.Chunker, "size", cfg.ChunkSize, "overlap", chunkOverlap)
+
+	// Initialize RAG pipeline
+	pipelineOpts := []rag.PipelineOption{
+		rag.WithKeywordWeight(cfg.KeywordWeight),
+		rag.WithMinScore(float32(cfg.MinScore)),
+		rag.WithEmbeddingModel(cfg.OpenAIEmbedModel),
+		rag.WithChunkOverlap(chunkOverlap),
+	}
+	switch cfg.Reranker {
+	case "llm":
+		pipelineOpts = append(pipelineOpts, rag.WithReranker(rag.NewLLMReranker(llmClient), cfg.RerankCandidates))
+	case "http":
+		pipelineOpts = append(pipelineOpts, rag.WithReranker(rag.NewHTTPReranker(cfg.RerankerURL, cfg.RerankerModel), cfg.RerankCandidates))
+	}
+	if cfg.Reranker != "none" {
+		slog.Info("Initialized reranker", "type", cfg.Reranker, "candidates", cfg.RerankCandidates)
+	}
+	if cfg.MMREnabled {
+		pipelineOpts = append(pipelineOpts, rag.WithMMR(cfg.MMRLambda, cfg.MMRCandidates))
+		slog.Info("Enabled MMR diversification", "lambda", cfg.MMRLambda, "candidates", cfg.MMRCandidates)
+	}
+	switch cfg.ContextExpansion {
+	case "neighbors":
+		pipelineOpts = append(pipelineOpts, rag.WithNeighborExpansion(cfg.ContextWindow))
+	case "parent":
+		pipelineOpts = append(pipelineOpts, rag.WithParentExpansion(cfg.ContextWindow))
+	}
+	if cfg.ContextExpansion != "none" {
+		slog.Info("Enabled context expansion", "type", cfg.ContextExpansion, "window", cfg.ContextWindow)
+	}
+
+	pipeline, err := rag.NewPipeline(chunker, llmClient, vectorDB, uint64(embedDimension), cfg.SearchLimit, pipelineOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RAG pipeline: %w", err)
+	}
+
+	// Load the in-process keyword index up front when hybrid search is the default; otherwise the
+	// first request with a keyword weight loads it
+	if cfg.KeywordWeight > 0 {
+		if err := pipeline.LoadKeywordIndex(ctx); err != nil {
+			return nil, err
+		}
+	}
+	slog.Info("Initialized RAG pipeline")
+
+	return pipeline, nil
+}
@@ -12,10 +12,20 @@ type Config struct {
 	// Server configuration
 	ServerPort string
 
+	// LLM provider configuration
+	LLMProvider string
+	LLMBaseURL  string
+
 	// OpenAI configuration
 	OpenAIAPIKey     string
 	OpenAIModel      string
 	OpenAIEmbedModel string
+	EmbedBatchSize   int
+	EmbedDimensions  int
+
+	// Vector store configuration
+	VectorStore     string
+	MemoryStorePath string
 
 	// Qdrant configuration
 	QdrantHost       string
@@ -23,9 +33,37 @@ type Config struct {
 	QdrantCollection string
 
 	// RAG configuration
-	ChunkSize    int
-	ChunkOverlap int
-	SearchLimit  int
+	Chunker            string
+	ChunkSize          int
+	ChunkOverlap       int
+	TokenizerEncoding  string
+	TokenizerPath      string
+	SemanticMinSize    int
+	SemanticPercentile float64
+	SearchLimit        int
+	MinScore           float64
+	KeywordWeight      float64
+
+	// Reranking configuration
+	Reranker         string
+	RerankerURL      string
+	RerankerModel    string
+	RerankCandidates int
+
+	// MMR diversification configuration
+	MMREnabled    bool
+	MMRLambda     float64
+	MMRCandidates int
+
+	// Small-to-big context expansion configuration
+	ContextExpansion string
+	ContextWindow    int
+
+	// Conversation history configuration
+	HistoryStore            string
+	HistoryPath             string
+	HistoryMaxMessages      int
+	HistoryMaxConversations int
 }
 
 // LoadConfig loads configuration from environment variables and command-line flags
@@ -35,33 +73,166 @@ func LoadConfig() (*Config, error) {
 
 	// Define flags
 	serverPort := flag.String("server-port", getEnv("SERVER_PORT", "8080"), "Server port")
+	llmProvider := flag.String("llm-provider", getEnv("LLM_PROVIDER", "openai"), "LLM provider: openai, openai-compatible or ollama")
+	llmBaseURL := flag.String("llm-base-url", getEnv("LLM_BASE_URL", ""), "Base URL of an OpenAI-compatible API (e.g. http://localhost:11434/v1)")
 	openAIKey := flag.String("openai-key", getEnv("OPENAI_API_KEY", ""), "OpenAI API key")
 	openAIModel := flag.String("openai-model", getEnv("OPENAI_MODEL", "gpt-4.1-mini"), "OpenAI model for chat completions")
 	openAIEmbedModel := flag.String("openai-embed-model", getEnv("OPENAI_EMBED_MODEL", "text-embedding-3-large"), "OpenAI model for embeddings")
+	embedBatchSize := flag.Int("embed-batch-size", getEnvAsInt("EMBED_BATCH_SIZE", 100), "Number of texts per embeddings request")
+	embedDimensions := flag.Int("embed-dimensions", getEnvAsInt("EMBED_DIMENSIONS", 0), "Embedding dimension requested from the API (0 = model default)")
+	vectorStore := flag.String("vector-store", getEnv("VECTOR_STORE", "qdrant"), "Vector store: qdrant or memory")
+	memoryStorePath := flag.String("memory-store-path", getEnv("MEMORY_STORE_PATH", ""), "File to persist the in-memory vector store to (empty = no persistence)")
 	qdrantHost := flag.String("qdrant-host", getEnv("QDRANT_HOST", "localhost"), "Qdrant host")
 	qdrantPort := flag.Int("qdrant-port", getEnvAsInt("QDRANT_PORT", 6334), "Qdrant gRPC port (default: 6334)")
 	qdrantCollection := flag.String("qdrant-collection", getEnv("QDRANT_COLLECTION", "docs"), "Qdrant collection name")
+	chunker := flag.String("chunker", getEnv("CHUNKER", "fixed"), "Chunker: fixed, markdown, recursive, token, semantic or code")
 	chunkSize := flag.Int("chunk-size", getEnvAsInt("CHUNK_SIZE", 1000), "Text chunk size")
 	chunkOverlap := flag.Int("chunk-overlap", getEnvAsInt("CHUNK_OVERLAP", 200), "Text chunk overlap")
+	tokenizerEncoding := flag.String("tokenizer-encoding", getEnv("TOKENIZER_ENCODING", "cl100k_base"), "BPE encoding of the token chunker: cl100k_base or o200k_base")
+	tokenizerPath := flag.String("tokenizer-path", getEnv("TOKENIZER_PATH", ""), "Path to the .tiktoken ranks file of the tokenizer encoding")
+	semanticMinSize := flag.Int("semantic-min-size", getEnvAsInt("SEMANTIC_MIN_SIZE", 200), "Minimum chunk size in characters before the semantic chunker may split")
+	semanticPercentile := flag.Float64("semantic-percentile", getEnvAsFloat("SEMANTIC_PERCENTILE", 10), "Percentile of adjacent sentence similarities below which the semantic chunker splits")
 	searchLimit := flag.Int("search-limit", getEnvAsInt("SEARCH_LIMIT", 3), "Number of search results to return")
+	minScore := flag.Float64("min-score", getEnvAsFloat("MIN_SCORE", 0), "Minimum similarity score of retrieved chunks (0 = no threshold)")
+	keywordWeight := flag.Float64("keyword-weight", getEnvAsFloat("KEYWORD_WEIGHT", 0), "Share of BM25 keyword ranking in hybrid retrieval (0 = dense only, 1 = keywords only)")
+
+	reranker := flag.String("reranker", getEnv("RERANKER", "none"), "Reranker: none, llm or http")
+	rerankerURL := flag.String("reranker-url", getEnv("RERANKER_URL", ""), "Rerank endpoint for the http reranker (e.g. http://localhost:8082/v1/rerank)")
+	rerankerModel := flag.String("reranker-model", getEnv("RERANKER_MODEL", ""), "Model name sent to the rerank endpoint")
+	rerankCandidates := flag.Int("rerank-candidates", getEnvAsInt("RERANK_CANDIDATES", 20), "Number of search candidates fetched for reranking")
+	mmrEnabled := flag.Bool("mmr", getEnvAsBool("MMR_ENABLED", false), "Diversify retrieved chunks with Maximal Marginal Relevance")
+	mmrLambda := flag.Float64("mmr-lambda", getEnvAsFloat("MMR_LAMBDA", 0.7), "MMR trade-off between relevance (1) and diversity (0)")
+	mmrCandidates := flag.Int("mmr-candidates", getEnvAsInt("MMR_CANDIDATES", 20), "Number of search candidates MMR selects from")
+	contextExpansion := flag.String("context-expansion", getEnv("CONTEXT_EXPANSION", "none"), "Expand retrieved chunks for the LLM context: none, neighbors or parent")
+	contextWindow := flag.Int("context-window", getEnvAsInt("CONTEXT_WINDOW", 1), "Chunks added on each side of a retrieved chunk (0 = whole parent section)")
+	historyStore := flag.String("history-store", getEnv("HISTORY_STORE", "memory"), "Conversation history store: none, memory or file")
+	historyPath := flag.String("history-path", getEnv("HISTORY_PATH", "data/conversations"), "Directory of the file history store")
+	historyMaxMessages := flag.Int("history-max-messages", getEnvAsInt("HISTORY_MAX_MESSAGES", 20), "Number of latest messages kept per conversation (0 = unlimited)")
+	historyMaxConversations := flag.Int("history-max-conversations", getEnvAsInt("HISTORY_MAX_CONVERSATIONS", 1000), "Number of most recently used conversations kept by the memory history store (0 = unlimited)")
 
 	flag.Parse()
 
 	// Set config values
 	cfg.ServerPort = *serverPort
+	cfg.LLMProvider = *llmProvider
+	cfg.LLMBaseURL = *llmBaseURL
 	cfg.OpenAIAPIKey = *openAIKey
 	cfg.OpenAIModel = *openAIModel
 	cfg.OpenAIEmbedModel = *openAIEmbedModel
+	cfg.EmbedBatchSize = *embedBatchSize
+	cfg.EmbedDimensions = *embedDimensions
+	cfg.VectorStore = *vectorStore
+	cfg.MemoryStorePath = *memoryStorePath
 	cfg.QdrantHost = *qdrantHost
 	cfg.QdrantPort = *qdrantPort
 	cfg.QdrantCollection = *qdrantCollection
+	cfg.Chunker = *chunker
 	cfg.ChunkSize = *chunkSize
 	cfg.ChunkOverlap = *chunkOverlap
+	cfg.TokenizerEncoding = *tokenizerEncoding
+	cfg.TokenizerPath = *tokenizerPath
+	cfg.SemanticMinSize = *semanticMinSize
+	cfg.SemanticPercentile = *semanticPercentile
 	cfg.SearchLimit = *searchLimit
+	cfg.MinScore = *minScore
+	cfg.KeywordWeight = *keywordWeight
+	cfg.Reranker = *reranker
+	cfg.RerankerURL = *rerankerURL
+	cfg.RerankerModel = *rerankerModel
+	cfg.RerankCandidates = *rerankCandidates
+	cfg.MMREnabled = *mmrEnabled
+	cfg.MMRLambda = *mmrLambda
+	cfg.MMRCandidates = *mmrCandidates
+	cfg.ContextExpansion = *contextExpansion
+	cfg.ContextWindow = *contextWindow
+	cfg.HistoryStore = *historyStore
+	cfg.HistoryPath = *historyPath
+	cfg.HistoryMaxMessages = *historyMaxMessages
+	cfg.HistoryMaxConversations = *historyMaxConversations
 
 	// Validate required fields
-	if cfg.OpenAIAPIKey == "" {
-		return nil, fmt.Errorf("OPENAI_API_KEY is required (set via environment variable or -openai-key flag)")
+	switch cfg.LLMProvider {
+	case "openai":
+		if cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY is required (set via environment variable or -openai-key flag)")
+		}
+	case "openai-compatible":
+		if cfg.LLMBaseURL == "" {
+			return nil, fmt.Errorf("LLM_BASE_URL is required for the openai-compatible provider (set via environment variable or -llm-base-url flag)")
+		}
+	case "ollama":
+		// Keyless local provider, base URL defaults to the local Ollama server
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q (expected openai, openai-compatible or ollama)", cfg.LLMProvider)
+	}
+
+	switch cfg.VectorStore {
+	case "qdrant", "memory":
+	default:
+		return nil, fmt.Errorf("unknown VECTOR_STORE %q (expected qdrant or memory)", cfg.VectorStore)
+	}
+
+	switch cfg.Chunker {
+	case "fixed", "markdown", "recursive", "code":
+	case "semantic":
+		if cfg.SemanticPercentile < 0 || cfg.SemanticPercentile > 100 {
+			return nil, fmt.Errorf("SEMANTIC_PERCENTILE must be between 0 and 100, got %g", cfg.SemanticPercentile)
+		}
+	case "token":
+		switch cfg.TokenizerEncoding {
+		case "cl100k_base", "o200k_base":
+		default:
+			return nil, fmt.Errorf("unknown TOKENIZER_ENCODING %q (expected cl100k_base or o200k_base)", cfg.TokenizerEncoding)
+		}
+		if cfg.TokenizerPath == "" {
+			return nil, fmt.Errorf("TOKENIZER_PATH is required for the token chunker (set via environment variable or -tokenizer-path flag)")
+		}
+	default:
+		return nil, fmt.Errorf("unknown CHUNKER %q (expected fixed, markdown, recursive, token, semantic or code)", cfg.Chunker)
+	}
+
+	if cfg.KeywordWeight < 0 || cfg.KeywordWeight > 1 {
+		return nil, fmt.Errorf("KEYWORD_WEIGHT must be between 0 and 1, got %g", cfg.KeywordWeight)
+	}
+
+	switch cfg.Reranker {
+	case "none", "llm":
+	case "http":
+		if cfg.RerankerURL == "" {
+			return nil, fmt.Errorf("RERANKER_URL is required for the http reranker (set via environment variable or -reranker-url flag)")
+		}
+	default:
+		return nil, fmt.Errorf("unknown RERANKER %q (expected none, llm or http)", cfg.Reranker)
+	}
+
+	if cfg.MMRLambda < 0 || cfg.MMRLambda > 1 {
+		return nil, fmt.Errorf("MMR_LAMBDA must be between 0 and 1, got %g", cfg.MMRLambda)
+	}
+
+	switch cfg.ContextExpansion {
+	case "none", "neighbors", "parent":
+	default:
+		return nil, fmt.Errorf("unknown CONTEXT_EXPANSION %q (expected none, neighbors or parent)", cfg.ContextExpansion)
+	}
+	if cfg.ContextWindow < 0 {
+		return nil, fmt.Errorf("CONTEXT_WINDOW must not be negative, got %d", cfg.ContextWindow)
+	}
+
+	switch cfg.HistoryStore {
+	case "none", "memory":
+	case "file":
+		if cfg.HistoryPath == "" {
+			return nil, fmt.Errorf("HISTORY_PATH is required for the file history store (set via environment variable or -history-path flag)")
+		}
+	default:
+		return nil, fmt.Errorf("unknown HISTORY_STORE %q (expected none, memory or file)", cfg.HistoryStore)
+	}
+
+	if cfg.HistoryMaxMessages < 0 {
+		return nil, fmt.Errorf("HISTORY_MAX_MESSAGES must not be negative, got %d", cfg.HistoryMaxMessages)
+	}
+	if cfg.HistoryMaxConversations < 0 {
+		return nil, fmt.Errorf("HISTORY_MAX_CONVERSATIONS must not be negative, got %d", cfg.HistoryMaxConversations)
 	}
 
 	return cfg, nil
@@ -84,3 +255,23 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsFloat gets an environment variable as a float or returns a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as a boolean or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
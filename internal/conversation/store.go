@@ -0,0 +1,203 @@
+package conversation
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// ErrInvalidConversationID is returned for conversation IDs that are empty or contain unsupported characters
+var ErrInvalidConversationID = errors.New("invalid conversation ID")
+
+// conversationIDPattern limits IDs to characters that are safe in file names
+var conversationIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// ValidateID checks that a conversation ID can be used as a history key
+func ValidateID(conversationID string) error {
+	if !conversationIDPattern.MatchString(conversationID) || conversationID == "." || conversationID == ".." {
+		return fmt.Errorf("%w %q: use 1-128 letters, digits, '.', '_' or '-'", ErrInvalidConversationID, conversationID)
+	}
+	return nil
+}
+
+// MemoryStore keeps conversation histories in process memory. When it holds more than the
+// maximum number of conversations, the least recently used one is dropped.
+type MemoryStore struct {
+	mu               sync.Mutex
+	maxMessages      int
+	maxConversations int
+	histories        map[string]*list.Element
+	// recent orders the conversations from most to least recently used
+	recent *list.List
+}
+
+// memoryConversation is a conversation held by the memory store
+type memoryConversation struct {
+	id       string
+	messages []types.ChatMessage
+}
+
+// NewMemoryStore creates an in-memory history store keeping at most maxMessages per conversation
+// and at most maxConversations conversations (0 keeps everything)
+func NewMemoryStore(maxMessages, maxConversations int) *MemoryStore {
+	return &MemoryStore{
+		maxMessages:      maxMessages,
+		maxConversations: maxConversations,
+		histories:        make(map[string]*list.Element),
+		recent:           list.New(),
+	}
+}
+
+// History returns the messages of a conversation, oldest first
+func (s *MemoryStore) History(ctx context.Context, conversationID string) ([]types.ChatMessage, error) {
+	if err := ValidateID(conversationID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.histories[conversationID]
+	if !ok {
+		return nil, nil
+	}
+	s.recent.MoveToFront(element)
+	return append([]types.ChatMessage(nil), element.Value.(*memoryConversation).messages...), nil
+}
+
+// Append adds messages to the end of a conversation
+func (s *MemoryStore) Append(ctx context.Context, conversationID string, messages ...types.ChatMessage) error {
+	if err := ValidateID(conversationID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.histories[conversationID]
+	if ok {
+		s.recent.MoveToFront(element)
+	} else {
+		element = s.recent.PushFront(&memoryConversation{id: conversationID})
+		s.histories[conversationID] = element
+	}
+	conversation := element.Value.(*memoryConversation)
+	conversation.messages = trim(append(conversation.messages, messages...), s.maxMessages)
+
+	if s.maxConversations > 0 && s.recent.Len() > s.maxConversations {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		delete(s.histories, oldest.Value.(*memoryConversation).id)
+	}
+	return nil
+}
+
+// FileStore keeps every conversation history in its own JSON file in a directory,
+// so conversations survive restarts
+type FileStore struct {
+	mu          sync.Mutex
+	dir         string
+	maxMessages int
+}
+
+// NewFileStore creates a file-backed history store in dir, keeping at most maxMessages per
+// conversation (0 keeps everything). The directory is created if it does not exist.
+func NewFileStore(dir string, maxMessages int) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &FileStore{
+		dir:         dir,
+		maxMessages: maxMessages,
+	}, nil
+}
+
+// History returns the messages of a conversation, oldest first
+func (s *FileStore) History(ctx context.Context, conversationID string) ([]types.ChatMessage, error) {
+	if err := ValidateID(conversationID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(conversationID)
+}
+
+// Append adds messages to the end of a conversation
+func (s *FileStore) Append(ctx context.Context, conversationID string, messages ...types.ChatMessage) error {
+	if err := ValidateID(conversationID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := s.read(conversationID)
+	if err != nil {
+		return err
+	}
+	history = trim(append(history, messages...), s.maxMessages)
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, conversationID+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(conversationID)); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	return nil
+}
+
+// read loads a conversation history; a missing file means an empty history
+func (s *FileStore) read(conversationID string) ([]types.ChatMessage, error) {
+	data, err := os.ReadFile(s.path(conversationID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var history []types.ChatMessage
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to decode history of %q: %w", conversationID, err)
+	}
+	return history, nil
+}
+
+// path returns the history file of a conversation
+func (s *FileStore) path(conversationID string) string {
+	return filepath.Join(s.dir, conversationID+".json")
+}
+
+// trim keeps the last maxMessages messages; 0 keeps everything
+func trim(history []types.ChatMessage, maxMessages int) []types.ChatMessage {
+	if maxMessages <= 0 || len(history) <= maxMessages {
+		return history
+	}
+	return append([]types.ChatMessage(nil), history[len(history)-maxMessages:]...)
+}
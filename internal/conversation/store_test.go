@@ -0,0 +1,163 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// historyStore is implemented by both stores
+type historyStore interface {
+	History(ctx context.Context, conversationID string) ([]types.ChatMessage, error)
+	Append(ctx context.Context, conversationID string, messages ...types.ChatMessage) error
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T, maxMessages int) historyStore{
+		"memory": func(t *testing.T, maxMessages int) historyStore {
+			return NewMemoryStore(maxMessages, 0)
+		},
+		"file": func(t *testing.T, maxMessages int) historyStore {
+			store, err := NewFileStore(t.TempDir(), maxMessages)
+			if err != nil {
+				t.Fatalf("NewFileStore() unexpected error: %v", err)
+			}
+			return store
+		},
+	}
+
+	turn := func(question, answer string) []types.ChatMessage {
+		return []types.ChatMessage{
+			{Role: types.RoleUser, Content: question},
+			{Role: types.RoleAssistant, Content: answer},
+		}
+	}
+
+	for name, newStore := range stores {
+		t.Run(name+"/append and read", func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t, 0)
+
+			history, err := store.History(ctx, "conv-1")
+			if err != nil {
+				t.Fatalf("History() unexpected error: %v", err)
+			}
+			if len(history) != 0 {
+				t.Errorf("History() of a new conversation = %+v, want empty", history)
+			}
+
+			if err := store.Append(ctx, "conv-1", turn("q1", "a1")...); err != nil {
+				t.Fatalf("Append() unexpected error: %v", err)
+			}
+			if err := store.Append(ctx, "conv-1", turn("q2", "a2")...); err != nil {
+				t.Fatalf("Append() unexpected error: %v", err)
+			}
+			if err := store.Append(ctx, "conv-2", turn("other", "conversation")...); err != nil {
+				t.Fatalf("Append() unexpected error: %v", err)
+			}
+
+			history, err = store.History(ctx, "conv-1")
+			if err != nil {
+				t.Fatalf("History() unexpected error: %v", err)
+			}
+			want := append(turn("q1", "a1"), turn("q2", "a2")...)
+			if !reflect.DeepEqual(history, want) {
+				t.Errorf("History() = %+v, want %+v", history, want)
+			}
+		})
+
+		t.Run(name+"/keeps latest messages", func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t, 2)
+
+			for _, q := range []string{"q1", "q2", "q3"} {
+				if err := store.Append(ctx, "conv-1", turn(q, "a"+q[1:])...); err != nil {
+					t.Fatalf("Append() unexpected error: %v", err)
+				}
+			}
+
+			history, err := store.History(ctx, "conv-1")
+			if err != nil {
+				t.Fatalf("History() unexpected error: %v", err)
+			}
+			if want := turn("q3", "a3"); !reflect.DeepEqual(history, want) {
+				t.Errorf("History() = %+v, want %+v", history, want)
+			}
+		})
+
+		t.Run(name+"/rejects invalid IDs", func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t, 0)
+
+			for _, id := range []string{"", "..", "../etc/passwd", "a/b", "with space"} {
+				if _, err := store.History(ctx, id); !errors.Is(err, ErrInvalidConversationID) {
+					t.Errorf("History(%q) error = %v, want ErrInvalidConversationID", id, err)
+				}
+				if err := store.Append(ctx, id, turn("q", "a")...); !errors.Is(err, ErrInvalidConversationID) {
+					t.Errorf("Append(%q) error = %v, want ErrInvalidConversationID", id, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStore_DropsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0, 2)
+	message := types.ChatMessage{Role: types.RoleUser, Content: "q"}
+
+	for _, id := range []string{"conv-1", "conv-2"} {
+		if err := store.Append(ctx, id, message); err != nil {
+			t.Fatalf("Append() unexpected error: %v", err)
+		}
+	}
+	// Reading conv-1 makes conv-2 the least recently used conversation
+	if _, err := store.History(ctx, "conv-1"); err != nil {
+		t.Fatalf("History() unexpected error: %v", err)
+	}
+	if err := store.Append(ctx, "conv-3", message); err != nil {
+		t.Fatalf("Append() unexpected error: %v", err)
+	}
+
+	for id, wantLen := range map[string]int{"conv-1": 1, "conv-2": 0, "conv-3": 1} {
+		history, err := store.History(ctx, id)
+		if err != nil {
+			t.Fatalf("History() unexpected error: %v", err)
+		}
+		if len(history) != wantLen {
+			t.Errorf("History(%q) = %+v, want %d messages", id, history, wantLen)
+		}
+	}
+}
+
+func TestFileStore_Persists(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore() unexpected error: %v", err)
+	}
+	messages := []types.ChatMessage{
+		{Role: types.RoleUser, Content: "Что такое Kubernetes?"},
+		{Role: types.RoleAssistant, Content: "Система оркестрации контейнеров."},
+	}
+	if err := store.Append(ctx, "conv-1", messages...); err != nil {
+		t.Fatalf("Append() unexpected error: %v", err)
+	}
+
+	reopened, err := NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore() unexpected error: %v", err)
+	}
+	history, err := reopened.History(ctx, "conv-1")
+	if err != nil {
+		t.Fatalf("History() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(history, messages) {
+		t.Errorf("History() after reopening = %+v, want %+v", history, messages)
+	}
+}
@@ -0,0 +1,142 @@
+// Package extract detects the format of uploaded documents and extracts their text for ingestion.
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported document formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatCode     = "code"
+)
+
+// ErrUnsupportedFormat is returned for documents that are not text in a supported format
+var ErrUnsupportedFormat = errors.New("unsupported document format")
+
+// extensionFormats maps file extensions to formats
+var extensionFormats = map[string]string{
+	".txt":      FormatText,
+	".text":     FormatText,
+	".log":      FormatText,
+	".md":       FormatMarkdown,
+	".markdown": FormatMarkdown,
+	".html":     FormatHTML,
+	".htm":      FormatHTML,
+	".json":     FormatJSON,
+	".csv":      FormatCSV,
+	// Source code keeps its blank lines, so that line numbers match the file
+	".go": FormatCode, ".c": FormatCode, ".h": FormatCode, ".cc": FormatCode, ".cpp": FormatCode,
+	".hpp": FormatCode, ".cs": FormatCode, ".java": FormatCode, ".kt": FormatCode, ".scala": FormatCode,
+	".swift": FormatCode, ".rs": FormatCode, ".js": FormatCode, ".jsx": FormatCode, ".mjs": FormatCode,
+	".ts": FormatCode, ".tsx": FormatCode, ".php": FormatCode, ".dart": FormatCode, ".py": FormatCode,
+	".rb": FormatCode, ".proto": FormatCode,
+}
+
+// mediaTypeFormats maps MIME types to formats
+var mediaTypeFormats = map[string]string{
+	"text/plain":       FormatText,
+	"text/markdown":    FormatMarkdown,
+	"text/x-markdown":  FormatMarkdown,
+	"text/html":        FormatHTML,
+	"application/json": FormatJSON,
+	"text/csv":         FormatCSV,
+}
+
+// Detect returns the format of a document from its file extension, then its declared
+// content type, then its content. Content that is not valid UTF-8 is rejected.
+func Detect(filename, contentType string, data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%w: content is not UTF-8 text", ErrUnsupportedFormat)
+	}
+
+	if format, ok := extensionFormats[strings.ToLower(filepath.Ext(filename))]; ok {
+		return format, nil
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if format, ok := mediaTypeFormats[mediaType]; ok {
+			return format, nil
+		}
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if (bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("["))) && json.Valid(trimmed) {
+		return FormatJSON, nil
+	}
+	if strings.HasPrefix(http.DetectContentType(data), "text/html") {
+		return FormatHTML, nil
+	}
+
+	return FormatText, nil
+}
+
+// Text extracts clean text from a document in the given format. Markdown keeps its markup,
+// which structure-aware chunking relies on.
+func Text(format string, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+
+	switch format {
+	case FormatText, FormatMarkdown:
+		text = string(data)
+	case FormatHTML:
+		text, err = htmlText(data)
+	case FormatJSON:
+		text, err = jsonText(data)
+	case FormatCSV:
+		text, err = csvText(data)
+	case FormatCode:
+		return normalizeLineEndings(string(data)), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s text: %w", format, err)
+	}
+
+	return normalizeText(text), nil
+}
+
+// normalizeText unifies line endings, drops a byte order mark and trailing spaces, and
+// collapses runs of blank lines
+func normalizeText(text string) string {
+	lines := strings.Split(normalizeLineEndings(text), "\n")
+	normalized := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		normalized = append(normalized, line)
+	}
+
+	return strings.TrimSpace(strings.Join(normalized, "\n"))
+}
+
+// normalizeLineEndings drops a byte order mark and unifies line endings
+func normalizeLineEndings(text string) string {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
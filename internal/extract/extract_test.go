@@ -0,0 +1,141 @@
+package extract
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		data        string
+		want        string
+		wantErr     error
+	}{
+		{name: "text by extension", filename: "notes.txt", data: "plain", want: FormatText},
+		{name: "markdown by extension", filename: "README.MD", data: "# Title", want: FormatMarkdown},
+		{name: "html by extension", filename: "page.htm", data: "<p>x</p>", want: FormatHTML},
+		{name: "json by extension", filename: "config.json", data: `{"a": 1}`, want: FormatJSON},
+		{name: "csv by extension", filename: "table.csv", data: "a,b", want: FormatCSV},
+		{name: "markdown by content type", filename: "notes", contentType: "text/markdown; charset=utf-8", data: "# Title", want: FormatMarkdown},
+		{name: "go source by extension", filename: "pipeline.go", data: "package rag", want: FormatCode},
+		{name: "csv by content type", filename: "export", contentType: "text/csv", data: "a,b", want: FormatCSV},
+		{name: "json by content", filename: "upload", contentType: "application/octet-stream", data: "  [1, 2]", want: FormatJSON},
+		{name: "html by content", filename: "upload", data: "<!DOCTYPE html><html><body>x</body></html>", want: FormatHTML},
+		{name: "invalid json is text", filename: "upload", data: "{not json", want: FormatText},
+		{name: "russian text", filename: "upload", data: "Привет, мир", want: FormatText},
+		{name: "binary is rejected", filename: "image.png", data: "\x89PNG\r\n\x1a\n\xff\xfe", wantErr: ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect(tt.filename, tt.contentType, []byte(tt.data))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Detect() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Detect() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "text line endings and blank lines are normalized",
+			format: FormatText,
+			data:   "\uFEFFfirst  \r\n\r\n\r\n\r\nsecond\r\n",
+			want:   "first\n\nsecond",
+		},
+		{
+			name:   "markdown keeps its markup",
+			format: FormatMarkdown,
+			data:   "# Pods\n\n```yaml\nkind: Pod\n  name: x\n```\n",
+			want:   "# Pods\n\n```yaml\nkind: Pod\n  name: x\n```",
+		},
+		{
+			name:   "html drops scripts and keeps blocks",
+			format: FormatHTML,
+			data: `<html><head><title>Ignored</title><style>p{}</style></head><body>
+<h1>Pods</h1>
+<p>A <b>Pod</b> is the smallest
+   deployable unit.</p>
+<script>alert(1)</script>
+<ul><li>One</li><li>Two</li></ul>
+<table><tr><th>Flag</th><th>Default</th></tr><tr><td>--port</td><td>8080</td></tr></table>
+<pre>kind: Pod
+  name: x</pre>
+</body></html>`,
+			want: "Pods\nA Pod is the smallest deployable unit.\n- One\n- Two\nFlag | Default\n--port | 8080\nkind: Pod\n  name: x",
+		},
+		{
+			name:   "json is flattened to paths",
+			format: FormatJSON,
+			data:   `{"server": {"port": 8080, "tls": false}, "tags": ["a", "b"], "note": null}`,
+			want:   "note: null\nserver.port: 8080\nserver.tls: false\ntags[0]: a\ntags[1]: b",
+		},
+		{
+			name:   "csv rows are labelled by the header",
+			format: FormatCSV,
+			data:   "flag,default,description\n--port,8080,\"Listen port, TCP\"\n--verbose,,Verbose logs\n",
+			want:   "flag: --port; default: 8080; description: Listen port, TCP\nflag: --verbose; description: Verbose logs",
+		},
+		{
+			name:   "csv with semicolons",
+			format: FormatCSV,
+			data:   "параметр;значение\nтаймаут;30s\n",
+			want:   "параметр: таймаут; значение: 30s",
+		},
+		{
+			name:   "code keeps blank lines and indentation",
+			format: FormatCode,
+			data:   "\uFEFF\nfunc main() {\r\n\r\n\r\n\tx := 1  \r\n}\n",
+			want:   "\nfunc main() {\n\n\n\tx := 1  \n}\n",
+		},
+		{
+			name:    "invalid json",
+			format:  FormatJSON,
+			data:    `{"a":`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			format:  "pdf",
+			data:    "x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Text(tt.format, []byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Text() expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Text() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
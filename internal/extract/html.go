@@ -0,0 +1,140 @@
+package extract
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements hold no readable text
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+}
+
+// blockElements start a new line in the extracted text
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Tr: true, atom.Ul: true, atom.Br: true, atom.Body: true, atom.Caption: true,
+}
+
+// htmlText extracts the readable text of an HTML document: scripts, styles and the head are
+// dropped, block elements become lines, list items get a "- " marker, table cells are separated
+// by " | " and preformatted text keeps its layout
+func htmlText(data []byte) (string, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	var w lineWriter
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			if pre {
+				w.WriteString(n.Data)
+				return
+			}
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+				// Keep words of adjacent inline elements apart
+				if len(n.Data) > 0 && isSpace(n.Data[0]) && !w.atLineStart() {
+					w.WriteString(" ")
+				}
+				w.WriteString(text)
+				if isSpace(n.Data[len(n.Data)-1]) {
+					w.WriteString(" ")
+				}
+			}
+			return
+		case html.ElementNode:
+			if skippedElements[n.DataAtom] {
+				return
+			}
+		}
+
+		block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+		if block {
+			w.newLine()
+		}
+		switch n.DataAtom {
+		case atom.Li:
+			w.WriteString("- ")
+		case atom.Td, atom.Th:
+			if n.PrevSibling != nil {
+				w.WriteString(" | ")
+			}
+		}
+
+		pre = pre || n.DataAtom == atom.Pre
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, pre)
+		}
+
+		if block {
+			w.newLine()
+		}
+	}
+	walk(doc, false)
+
+	return w.String(), nil
+}
+
+// lineWriter builds text line by line, dropping the trailing spaces of every line. Trailing
+// spaces are held back until something other than a line break follows them, so that ending
+// a line never rewrites the text written so far.
+type lineWriter struct {
+	b strings.Builder
+	// spaces is the number of trailing spaces not written yet
+	spaces int
+}
+
+// WriteString appends s, holding back its trailing spaces
+func (w *lineWriter) WriteString(s string) {
+	trimmed := strings.TrimRight(s, " ")
+	if trimmed != "" {
+		w.b.WriteString(strings.Repeat(" ", w.spaces))
+		w.b.WriteString(trimmed)
+		w.spaces = 0
+	}
+	w.spaces += len(s) - len(trimmed)
+}
+
+// newLine ends the current line without its trailing spaces, unless the writer is already at a line start
+func (w *lineWriter) newLine() {
+	if !w.atLineStart() {
+		w.spaces = 0
+		w.b.WriteByte('\n')
+	}
+}
+
+// atLineStart reports whether nothing has been written yet or the text ends with a line break
+func (w *lineWriter) atLineStart() bool {
+	if w.spaces > 0 {
+		return false
+	}
+	s := w.b.String()
+	return s == "" || s[len(s)-1] == '\n'
+}
+
+// String returns the text written so far
+func (w *lineWriter) String() string {
+	return w.b.String() + strings.Repeat(" ", w.spaces)
+}
+
+// isSpace reports whether c is HTML whitespace
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,131 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonText flattens a JSON document into "path: value" lines, so that every value is
+// retrievable together with the keys that describe it
+func jsonText(data []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	flattenJSON(&b, "", value)
+	return b.String(), nil
+}
+
+// flattenJSON writes a line per scalar value with its dotted path; object keys are sorted
+func flattenJSON(b *strings.Builder, path string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenJSON(b, childPath, v[key])
+		}
+	case []any:
+		for i, item := range v {
+			flattenJSON(b, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	default:
+		var text string
+		switch s := v.(type) {
+		case nil:
+			text = "null"
+		case string:
+			text = s
+		case json.Number:
+			text = s.String()
+		case bool:
+			text = strconv.FormatBool(s)
+		}
+		if path == "" {
+			fmt.Fprintf(b, "%s\n", text)
+		} else {
+			fmt.Fprintf(b, "%s: %s\n", path, text)
+		}
+	}
+}
+
+// csvText turns every CSV record into a line of "column: value" pairs named by the header row.
+// The delimiter is detected from the header among comma, semicolon and tab.
+func csvText(data []byte) (string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\uFEFF"))
+	}
+
+	var b strings.Builder
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		fields := make([]string, 0, len(record))
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			if i < len(header) && header[i] != "" {
+				fields = append(fields, header[i]+": "+value)
+			} else {
+				fields = append(fields, value)
+			}
+		}
+		if len(fields) > 0 {
+			b.WriteString(strings.Join(fields, "; "))
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String(), nil
+}
+
+// detectDelimiter picks the most frequent of comma, semicolon and tab in the first line
+func detectDelimiter(data []byte) rune {
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	delimiter, best := ',', bytes.Count(firstLine, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if count := bytes.Count(firstLine, []byte(string(candidate))); count > best {
+			delimiter, best = candidate, count
+		}
+	}
+	return delimiter
+}
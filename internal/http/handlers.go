@@ -3,10 +3,18 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/conversation"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/extract"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
 	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
@@ -14,36 +22,79 @@ import (
 
 // LLMClient defines the interface for LLM answer generation
 type LLMClient interface {
-	GenerateAnswer(ctx context.Context, contextText, question string) (string, error)
+	GenerateAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage) (*types.Answer, error)
+	StreamAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage, onToken func(token string) error) (*types.Answer, error)
+	// CondenseQuestion rewrites a follow-up question into a standalone retrieval query
+	CondenseQuestion(ctx context.Context, history []types.ChatMessage, question string) (string, error)
 }
 
 //go:generate mockgen -source=handlers.go -destination=mock_ragpipeline.go -package=http RAGPipeline
 
 // RAGPipeline defines the interface for RAG pipeline operations
 type RAGPipeline interface {
-	Retrieve(ctx context.Context, query string) (string, error)
-	Ingest(ctx context.Context, text string, docID string) error
+	Retrieve(ctx context.Context, query string, opts rag.RetrieveOptions) ([]rag.SearchResult, error)
+	Ingest(ctx context.Context, text string, docID string, metadata map[string]any) error
+	Delete(ctx context.Context, docID string) error
+	ListDocuments(ctx context.Context, limit int, offset string) (*types.DocumentListResponse, error)
+	GetDocument(ctx context.Context, docID string) (*types.Document, error)
 }
 
+//go:generate mockgen -source=handlers.go -destination=mock_historystore.go -package=http HistoryStore
+
+// HistoryStore defines the interface for storing conversation histories
+type HistoryStore interface {
+	// History returns the messages of a conversation, oldest first
+	History(ctx context.Context, conversationID string) ([]types.ChatMessage, error)
+	Append(ctx context.Context, conversationID string, messages ...types.ChatMessage) error
+}
+
+const (
+	defaultDocumentsLimit = 20
+	maxDocumentsLimit     = 100
+)
+
+// maxUploadSize limits the size of a file uploaded to /ingest/file
+const maxUploadSize = 32 << 20
+
+// insufficientContextAnswer is returned without calling the LLM when no retrieved chunk is relevant enough
+const insufficientContextAnswer = "В базе знаний нет информации, достаточно близкой к этому вопросу, поэтому ответ не может быть дан."
+
 type QueryReq struct {
-	Query string `json:"query"`
+	Query         string             `json:"query"`
+	KeywordWeight *float64           `json:"keyword_weight,omitempty"`
+	Filter        *types.QueryFilter `json:"filter,omitempty"`
+	// ConversationID continues a conversation: earlier turns are used to understand
+	// follow-up questions and are passed to the LLM
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// retrieveOptions returns the per-query retrieval settings of the request
+func (req QueryReq) retrieveOptions() rag.RetrieveOptions {
+	return rag.RetrieveOptions{
+		KeywordWeight: req.KeywordWeight,
+		Filter:        req.Filter,
+	}
 }
 
 type IngestReq struct {
-	Text string `json:"text"`
-	ID   string `json:"id,omitempty"`
+	Text     string         `json:"text"`
+	ID       string         `json:"id,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
 type Handler struct {
-	ragPipeline RAGPipeline
-	llmClient   LLMClient
+	ragPipeline  RAGPipeline
+	llmClient    LLMClient
+	historyStore HistoryStore
 }
 
-// InitHandlers initializes handlers with dependencies
-func NewHandlers(ragPipeline RAGPipeline, llmClient LLMClient) *Handler {
+// InitHandlers initializes handlers with dependencies.
+// historyStore may be nil, in which case queries with a conversation_id are rejected.
+func NewHandlers(ragPipeline RAGPipeline, llmClient LLMClient, historyStore HistoryStore) *Handler {
 	return &Handler{
-		ragPipeline: ragPipeline,
-		llmClient:   llmClient,
+		ragPipeline:  ragPipeline,
+		llmClient:    llmClient,
+		historyStore: historyStore,
 	}
 }
 
@@ -61,10 +112,43 @@ func (h *Handler) QueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.KeywordWeight != nil && (*req.KeywordWeight < 0 || *req.KeywordWeight > 1) {
+		errorResponse(w, http.StatusBadRequest, "keyword_weight must be between 0 and 1", nil)
+		return
+	}
+
+	if req.ConversationID != "" && h.historyStore == nil {
+		errorResponse(w, http.StatusBadRequest, "Conversations are not enabled", nil)
+		return
+	}
+
 	ctx := r.Context()
+	start := time.Now()
+
+	history, retrievalQuery, err := h.prepareConversation(ctx, req)
+	if errors.Is(err, conversation.ErrInvalidConversationID) {
+		errorResponse(w, http.StatusBadRequest, "Invalid conversation_id", err)
+		return
+	}
+	if err != nil {
+		slog.Error("Error preparing conversation", "error", err, "conversation_id", req.ConversationID)
+		errorResponse(w, http.StatusInternalServerError, "Failed to load conversation", err)
+		return
+	}
 
 	// RAG pipeline - retrieve relevant context
-	contextText, err := h.ragPipeline.Retrieve(ctx, req.Query)
+	results, err := h.ragPipeline.Retrieve(ctx, retrievalQuery, req.retrieveOptions())
+	if errors.Is(err, rag.ErrNoRelevantDocuments) {
+		// Nothing to ground an answer on, skip generation
+		response := newInsufficientContextResponse(start)
+		h.saveTurn(ctx, req, &response)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.Error("Error encoding response", "error", err)
+		}
+		return
+	}
 	if err != nil {
 		slog.Error("Error retrieving context", "error", err, "query", req.Query)
 		errorResponse(w, http.StatusInternalServerError, "Failed to retrieve context", err)
@@ -72,16 +156,15 @@ func (h *Handler) QueryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// LLM generation
-	answer, err := h.llmClient.GenerateAnswer(ctx, contextText, req.Query)
+	answer, err := h.llmClient.GenerateAnswer(ctx, rag.BuildContext(results), req.Query, history)
 	if err != nil {
 		slog.Error("Error generating answer", "error", err, "query", req.Query)
 		errorResponse(w, http.StatusInternalServerError, "Failed to generate answer", err)
 		return
 	}
 
-	response := types.QueryResponse{
-		Answer: answer,
-	}
+	response := newQueryResponse(answer, results, start)
+	h.saveTurn(ctx, req, &response)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -89,6 +172,99 @@ func (h *Handler) QueryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// QueryStreamHandler answers a query like QueryHandler but streams the answer as Server-Sent Events:
+// a "token" event per generated token, then a "done" event with the full response and sources.
+func (h *Handler) QueryStreamHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req QueryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.Query == "" {
+		errorResponse(w, http.StatusBadRequest, "Query is required", nil)
+		return
+	}
+
+	if req.KeywordWeight != nil && (*req.KeywordWeight < 0 || *req.KeywordWeight > 1) {
+		errorResponse(w, http.StatusBadRequest, "keyword_weight must be between 0 and 1", nil)
+		return
+	}
+
+	if req.ConversationID != "" && h.historyStore == nil {
+		errorResponse(w, http.StatusBadRequest, "Conversations are not enabled", nil)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "Streaming is not supported", nil)
+		return
+	}
+
+	ctx := r.Context()
+	start := time.Now()
+
+	history, retrievalQuery, err := h.prepareConversation(ctx, req)
+	if errors.Is(err, conversation.ErrInvalidConversationID) {
+		errorResponse(w, http.StatusBadRequest, "Invalid conversation_id", err)
+		return
+	}
+	if err != nil {
+		slog.Error("Error preparing conversation", "error", err, "conversation_id", req.ConversationID)
+		errorResponse(w, http.StatusInternalServerError, "Failed to load conversation", err)
+		return
+	}
+
+	// RAG pipeline - retrieve relevant context
+	results, err := h.ragPipeline.Retrieve(ctx, retrievalQuery, req.retrieveOptions())
+	insufficientContext := errors.Is(err, rag.ErrNoRelevantDocuments)
+	if err != nil && !insufficientContext {
+		slog.Error("Error retrieving context", "error", err, "query", req.Query)
+		errorResponse(w, http.StatusInternalServerError, "Failed to retrieve context", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Nothing to ground an answer on, skip generation
+	if insufficientContext {
+		response := newInsufficientContextResponse(start)
+		h.saveTurn(ctx, req, &response)
+		if err := writeEvent(w, flusher, "done", response); err != nil {
+			slog.Error("Error writing done event", "error", err)
+		}
+		return
+	}
+
+	// LLM generation, forwarding tokens as they arrive
+	answer, err := h.llmClient.StreamAnswer(ctx, rag.BuildContext(results), req.Query, history, func(token string) error {
+		return writeEvent(w, flusher, "token", map[string]string{"token": token})
+	})
+	if err != nil {
+		slog.Error("Error generating answer", "error", err, "query", req.Query)
+		if err := writeEvent(w, flusher, "error", types.ErrorResponse{
+			Error:   http.StatusText(http.StatusInternalServerError),
+			Message: fmt.Sprintf("Failed to generate answer: %v", err),
+		}); err != nil {
+			slog.Error("Error writing error event", "error", err)
+		}
+		return
+	}
+
+	response := newQueryResponse(answer, results, start)
+	h.saveTurn(ctx, req, &response)
+	if err := writeEvent(w, flusher, "done", response); err != nil {
+		slog.Error("Error writing done event", "error", err)
+	}
+}
+
 func (h *Handler) IngestHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -106,7 +282,12 @@ func (h *Handler) IngestHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Ingest document into RAG pipeline
-	if err := h.ragPipeline.Ingest(ctx, req.Text, req.ID); err != nil {
+	err := h.ragPipeline.Ingest(ctx, req.Text, req.ID, req.Metadata)
+	if errors.Is(err, rag.ErrInvalidMetadata) {
+		errorResponse(w, http.StatusBadRequest, "Invalid metadata", err)
+		return
+	}
+	if err != nil {
 		slog.Error("Error ingesting document", "error", err, "doc_id", req.ID)
 		errorResponse(w, http.StatusInternalServerError, "Failed to ingest document", err)
 		return
@@ -118,6 +299,258 @@ func (h *Handler) IngestHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// IngestFileHandler ingests a document uploaded as multipart form data. The "file" field holds
+// the document; optional "id" (defaults to the file name) and "metadata" (a JSON object) fields
+// mirror /ingest. The format is detected and clean text is extracted before chunking; the file
+// name is recorded as the "source" metadata unless given explicitly.
+func (h *Handler) IngestFileHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid multipart form", err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "File is required", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Failed to read file", err)
+		return
+	}
+
+	metadata := map[string]any{}
+	if value := r.FormValue("metadata"); value != "" {
+		if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Metadata must be a JSON object", err)
+			return
+		}
+	}
+
+	// The multipart reader already strips directories from the file name
+	filename := header.Filename
+	if _, ok := metadata["source"]; !ok {
+		metadata["source"] = filename
+	}
+	docID := r.FormValue("id")
+	if docID == "" {
+		docID = filename
+	}
+
+	format, err := extract.Detect(filename, header.Header.Get("Content-Type"), data)
+	if err != nil {
+		errorResponse(w, http.StatusUnsupportedMediaType, "Unsupported file", err)
+		return
+	}
+	text, err := extract.Text(format, data)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Failed to extract text", err)
+		return
+	}
+	if text == "" {
+		errorResponse(w, http.StatusBadRequest, "File contains no text", nil)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Ingest document into RAG pipeline
+	err = h.ragPipeline.Ingest(ctx, text, docID, metadata)
+	if errors.Is(err, rag.ErrInvalidMetadata) {
+		errorResponse(w, http.StatusBadRequest, "Invalid metadata", err)
+		return
+	}
+	if err != nil {
+		slog.Error("Error ingesting file", "error", err, "doc_id", docID, "format", format)
+		errorResponse(w, http.StatusInternalServerError, "Failed to ingest document", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"status": "success",
+		"id":     docID,
+		"format": format,
+	}); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+func (h *Handler) ListDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultDocumentsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			errorResponse(w, http.StatusBadRequest, "Limit must be a positive integer", err)
+			return
+		}
+		limit = min(parsed, maxDocumentsLimit)
+	}
+	offset := r.URL.Query().Get("offset")
+
+	ctx := r.Context()
+
+	documents, err := h.ragPipeline.ListDocuments(ctx, limit, offset)
+	if err != nil {
+		slog.Error("Error listing documents", "error", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to list documents", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(documents); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+func (h *Handler) GetDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "id")
+	if docID == "" {
+		errorResponse(w, http.StatusBadRequest, "Document ID is required", nil)
+		return
+	}
+
+	ctx := r.Context()
+
+	document, err := h.ragPipeline.GetDocument(ctx, docID)
+	if errors.Is(err, rag.ErrDocumentNotFound) {
+		errorResponse(w, http.StatusNotFound, "Document not found", nil)
+		return
+	}
+	if err != nil {
+		slog.Error("Error getting document", "error", err, "doc_id", docID)
+		errorResponse(w, http.StatusInternalServerError, "Failed to get document", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+func (h *Handler) DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	docID := chi.URLParam(r, "id")
+	if docID == "" {
+		errorResponse(w, http.StatusBadRequest, "Document ID is required", nil)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Remove all chunks of the document from the RAG pipeline
+	if err := h.ragPipeline.Delete(ctx, docID); err != nil {
+		slog.Error("Error deleting document", "error", err, "doc_id", docID)
+		errorResponse(w, http.StatusInternalServerError, "Failed to delete document", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+// prepareConversation loads the history of the request's conversation and condenses a follow-up
+// question into a standalone retrieval query. Without a conversation the query is used as is.
+func (h *Handler) prepareConversation(ctx context.Context, req QueryReq) ([]types.ChatMessage, string, error) {
+	if req.ConversationID == "" {
+		return nil, req.Query, nil
+	}
+
+	history, err := h.historyStore.History(ctx, req.ConversationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(history) == 0 {
+		return nil, req.Query, nil
+	}
+
+	retrievalQuery, err := h.llmClient.CondenseQuestion(ctx, history, req.Query)
+	if err != nil {
+		return nil, "", err
+	}
+	slog.Debug("Condensed follow-up question", "query", req.Query, "retrieval_query", retrievalQuery)
+
+	return history, retrievalQuery, nil
+}
+
+// saveTurn appends the question and the answer to the request's conversation and tags the
+// response with the conversation ID. A failure to save is logged, the answer is still returned.
+func (h *Handler) saveTurn(ctx context.Context, req QueryReq, response *types.QueryResponse) {
+	if req.ConversationID == "" {
+		return
+	}
+	response.ConversationID = req.ConversationID
+
+	if err := h.historyStore.Append(ctx, req.ConversationID,
+		types.ChatMessage{Role: types.RoleUser, Content: req.Query},
+		types.ChatMessage{Role: types.RoleAssistant, Content: response.Answer},
+	); err != nil {
+		slog.Error("Error saving conversation", "error", err, "conversation_id", req.ConversationID)
+	}
+}
+
+// newQueryResponse assembles a query response from the generated answer and retrieved chunks
+func newQueryResponse(answer *types.Answer, results []rag.SearchResult, start time.Time) types.QueryResponse {
+	response := types.QueryResponse{
+		Answer:  answer.Text,
+		Context: make([]string, 0, len(results)),
+		Sources: make([]types.Source, 0, len(results)),
+		Metadata: map[string]interface{}{
+			"model":      answer.Model,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"usage":      answer.Usage,
+		},
+	}
+	for _, result := range results {
+		response.Context = append(response.Context, result.Text)
+		response.Sources = append(response.Sources, types.Source{
+			DocID:       result.DocID,
+			ChunkIndex:  result.ChunkIndex,
+			Score:       result.Score,
+			HeadingPath: result.HeadingPath,
+			Offsets:     result.Offsets,
+			FilePath:    result.FilePath,
+			Symbol:      result.Symbol,
+			StartLine:   result.StartLine,
+			EndLine:     result.EndLine,
+		})
+	}
+	return response
+}
+
+// newInsufficientContextResponse builds the response for a query without relevant context
+func newInsufficientContextResponse(start time.Time) types.QueryResponse {
+	return types.QueryResponse{
+		Answer:              insufficientContextAnswer,
+		InsufficientContext: true,
+		Metadata: map[string]interface{}{
+			"latency_ms": time.Since(start).Milliseconds(),
+		},
+	}
+}
+
+// writeEvent writes a single Server-Sent Event with a JSON payload and flushes it to the client
+func writeEvent(w http.ResponseWriter, flusher http.Flusher, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	flusher.Flush()
+	return nil
+}
+
 func errorResponse(w http.ResponseWriter, status int, message string, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -2,14 +2,19 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/conversation"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
 	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
@@ -28,15 +33,35 @@ func TestHandler_QueryHandler(t *testing.T) {
 			},
 			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
 				pipeline.EXPECT().
-					Retrieve(gomock.Any(), "What is Kubernetes?").
-					Return("Kubernetes is a container orchestration system", nil)
+					Retrieve(gomock.Any(), "What is Kubernetes?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "Kubernetes is a container orchestration system", Score: 0.9, DocID: "k8s", ChunkIndex: 2}}, nil)
 				llm.EXPECT().
-					GenerateAnswer(gomock.Any(), "Kubernetes is a container orchestration system", "What is Kubernetes?").
-					Return("Kubernetes is a container orchestration platform", nil)
+					GenerateAnswer(gomock.Any(), "[Document 1, Score: 0.9000]\nKubernetes is a container orchestration system", "What is Kubernetes?", nil).
+					Return(&types.Answer{Text: "Kubernetes is a container orchestration platform", Model: "gpt-4.1-mini"}, nil)
 			},
 			wantStatus:   http.StatusOK,
 			wantContains: "Kubernetes is a container orchestration platform",
 		},
+		{
+			name: "response includes sources and metadata",
+			requestBody: QueryReq{
+				Query: "What is Kubernetes?",
+			},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is Kubernetes?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "Kubernetes is a container orchestration system", Score: 0.9, DocID: "k8s", ChunkIndex: 2}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "What is Kubernetes?", nil).
+					Return(&types.Answer{
+						Text:  "Kubernetes is a container orchestration platform",
+						Model: "gpt-4.1-mini",
+						Usage: types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+					}, nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `"sources":[{"doc_id":"k8s","chunk_index":2,"score":0.9}]`,
+		},
 		{
 			name:        "invalid JSON",
 			requestBody: "invalid json",
@@ -51,6 +76,46 @@ func TestHandler_QueryHandler(t *testing.T) {
 			setupMocks: func(*MockRAGPipeline, *MockLLMClient) {},
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:        "keyword weight out of range",
+			requestBody: map[string]any{"query": "test query", "keyword_weight": 1.5},
+			setupMocks:  func(*MockRAGPipeline, *MockLLMClient) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name: "filter passed to retrieval",
+			requestBody: map[string]any{
+				"query":  "test query",
+				"filter": map[string]any{"doc_ids": []string{"a", "b"}, "source": "wiki"},
+			},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "test query", rag.RetrieveOptions{
+						Filter: &types.QueryFilter{DocIDs: []string{"a", "b"}, Source: "wiki"},
+					}).
+					Return([]rag.SearchResult{{Text: "chunk", Score: 0.8, DocID: "a"}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "test query", nil).
+					Return(&types.Answer{Text: "answer"}, nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: "answer",
+		},
+		{
+			name:        "keyword weight passed to retrieval",
+			requestBody: map[string]any{"query": "test query", "keyword_weight": 0.3},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				weight := 0.3
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "test query", rag.RetrieveOptions{KeywordWeight: &weight}).
+					Return([]rag.SearchResult{{Text: "chunk", Score: 0.02}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "test query", nil).
+					Return(&types.Answer{Text: "answer"}, nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: "answer",
+		},
 		{
 			name: "retrieve fails",
 			requestBody: QueryReq{
@@ -58,11 +123,24 @@ func TestHandler_QueryHandler(t *testing.T) {
 			},
 			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
 				pipeline.EXPECT().
-					Retrieve(gomock.Any(), "test query").
-					Return("", errors.New("retrieve error"))
+					Retrieve(gomock.Any(), "test query", gomock.Any()).
+					Return(nil, errors.New("retrieve error"))
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "insufficient context skips generation",
+			requestBody: QueryReq{
+				Query: "What is the weather on Mars?",
+			},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is the weather on Mars?", gomock.Any()).
+					Return(nil, fmt.Errorf("wrapped: %w", rag.ErrNoRelevantDocuments))
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `"insufficient_context":true`,
+		},
 		{
 			name: "LLM generation fails",
 			requestBody: QueryReq{
@@ -70,11 +148,11 @@ func TestHandler_QueryHandler(t *testing.T) {
 			},
 			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
 				pipeline.EXPECT().
-					Retrieve(gomock.Any(), "test query").
-					Return("context text", nil)
+					Retrieve(gomock.Any(), "test query", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "context text", Score: 0.5}}, nil)
 				llm.EXPECT().
-					GenerateAnswer(gomock.Any(), "context text", "test query").
-					Return("", errors.New("LLM error"))
+					GenerateAnswer(gomock.Any(), gomock.Any(), "test query", nil).
+					Return(nil, errors.New("LLM error"))
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
@@ -92,7 +170,7 @@ func TestHandler_QueryHandler(t *testing.T) {
 				tt.setupMocks(mockPipeline, mockLLM)
 			}
 
-			handler := NewHandlers(mockPipeline, mockLLM)
+			handler := NewHandlers(mockPipeline, mockLLM, nil)
 
 			var body []byte
 			var err error
@@ -124,6 +202,178 @@ func TestHandler_QueryHandler(t *testing.T) {
 	}
 }
 
+func TestHandler_QueryHandler_KeywordWeightWithoutLoadedIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The server default is dense only, so the keyword index was not loaded on startup
+	embedder := rag.NewMockLLMClient(ctrl)
+	embedder.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{{1, 0}}, nil)
+	store, err := rag.NewMemoryStore("")
+	if err != nil {
+		t.Fatalf("NewMemoryStore() unexpected error: %v", err)
+	}
+	pipeline, err := rag.NewPipeline(rag.NewChunker(100, 0), embedder, store, 2, 3, rag.WithKeywordWeight(0))
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	if err := pipeline.Ingest(context.Background(), "error code ERR_QUOTA_EXCEEDED", "errors", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().
+		GenerateAnswer(gomock.Any(), gomock.Any(), "err_quota_exceeded", nil).
+		Return(&types.Answer{Text: "The quota is exceeded."}, nil)
+	handler := NewHandlers(pipeline, mockLLM, nil)
+
+	body, err := json.Marshal(map[string]any{"query": "err_quota_exceeded", "keyword_weight": 1})
+	if err != nil {
+		t.Fatalf("Failed to marshal request body: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.QueryHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("QueryHandler() status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"doc_id":"errors"`)) || bytes.Contains(w.Body.Bytes(), []byte(`"insufficient_context":true`)) {
+		t.Errorf("QueryHandler() body = %s, want the errors chunk as source", w.Body.String())
+	}
+}
+
+func TestHandler_QueryStreamHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  interface{}
+		setupMocks   func(*MockRAGPipeline, *MockLLMClient)
+		wantStatus   int
+		wantContains []string
+	}{
+		{
+			name: "streams tokens then sources",
+			requestBody: QueryReq{
+				Query: "What is Kubernetes?",
+			},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is Kubernetes?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "Kubernetes is a container orchestration system", Score: 0.9, DocID: "k8s", ChunkIndex: 1}}, nil)
+				llm.EXPECT().
+					StreamAnswer(gomock.Any(), gomock.Any(), "What is Kubernetes?", nil, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, contextText, question string, history []types.ChatMessage, onToken func(string) error) (*types.Answer, error) {
+						for _, token := range []string{"Kuber", "netes"} {
+							if err := onToken(token); err != nil {
+								return nil, err
+							}
+						}
+						return &types.Answer{Text: "Kubernetes", Model: "gpt-4.1-mini"}, nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				"event: token\ndata: {\"token\":\"Kuber\"}\n\n",
+				"event: token\ndata: {\"token\":\"netes\"}\n\n",
+				"event: done\ndata: {\"answer\":\"Kubernetes\"",
+				`"sources":[{"doc_id":"k8s","chunk_index":1,"score":0.9}]`,
+			},
+		},
+		{
+			name:        "invalid JSON",
+			requestBody: "invalid json",
+			setupMocks:  func(*MockRAGPipeline, *MockLLMClient) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name: "retrieve fails before streaming",
+			requestBody: QueryReq{
+				Query: "test query",
+			},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "test query", gomock.Any()).
+					Return(nil, errors.New("retrieve error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "insufficient context skips generation",
+			requestBody: QueryReq{
+				Query: "test query",
+			},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "test query", gomock.Any()).
+					Return(nil, rag.ErrNoRelevantDocuments)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"event: done\n", `"insufficient_context":true`},
+		},
+		{
+			name: "generation fails mid-stream",
+			requestBody: QueryReq{
+				Query: "test query",
+			},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "test query", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "context text", Score: 0.5}}, nil)
+				llm.EXPECT().
+					StreamAnswer(gomock.Any(), gomock.Any(), "test query", nil, gomock.Any()).
+					Return(nil, errors.New("LLM error"))
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"event: error\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockRAGPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline, mockLLM)
+			}
+
+			handler := NewHandlers(mockPipeline, mockLLM, nil)
+
+			var body []byte
+			var err error
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, err = json.Marshal(tt.requestBody)
+				if err != nil {
+					t.Fatalf("Failed to marshal request body: %v", err)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/query/stream", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.QueryStreamHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("QueryStreamHandler() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			for _, want := range tt.wantContains {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("QueryStreamHandler() body = %s, want containing %q", w.Body.String(), want)
+				}
+			}
+		})
+	}
+}
+
 func TestHandler_IngestHandler(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -139,7 +389,7 @@ func TestHandler_IngestHandler(t *testing.T) {
 			},
 			setupMocks: func(pipeline *MockRAGPipeline) {
 				pipeline.EXPECT().
-					Ingest(gomock.Any(), "This is a test document", "doc1").
+					Ingest(gomock.Any(), "This is a test document", "doc1", gomock.Nil()).
 					Return(nil)
 			},
 			wantStatus: http.StatusOK,
@@ -167,11 +417,38 @@ func TestHandler_IngestHandler(t *testing.T) {
 			},
 			setupMocks: func(pipeline *MockRAGPipeline) {
 				pipeline.EXPECT().
-					Ingest(gomock.Any(), "test document", "doc1").
+					Ingest(gomock.Any(), "test document", "doc1", gomock.Nil()).
 					Return(errors.New("ingestion error"))
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "ingestion with metadata",
+			requestBody: map[string]any{
+				"text":     "test document",
+				"id":       "doc1",
+				"metadata": map[string]any{"source": "wiki", "tags": []string{"team-a"}},
+			},
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					Ingest(gomock.Any(), "test document", "doc1", map[string]any{"source": "wiki", "tags": []any{"team-a"}}).
+					Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "reserved metadata key",
+			requestBody: map[string]any{
+				"text":     "test document",
+				"metadata": map[string]any{"doc_id": "other"},
+			},
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					Ingest(gomock.Any(), "test document", "", gomock.Any()).
+					Return(fmt.Errorf("%w: key \"doc_id\" is reserved", rag.ErrInvalidMetadata))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name: "ingestion without ID",
 			requestBody: IngestReq{
@@ -180,7 +457,7 @@ func TestHandler_IngestHandler(t *testing.T) {
 			},
 			setupMocks: func(pipeline *MockRAGPipeline) {
 				pipeline.EXPECT().
-					Ingest(gomock.Any(), "test document", "").
+					Ingest(gomock.Any(), "test document", "", gomock.Nil()).
 					Return(nil)
 			},
 			wantStatus: http.StatusOK,
@@ -199,7 +476,7 @@ func TestHandler_IngestHandler(t *testing.T) {
 				tt.setupMocks(mockPipeline)
 			}
 
-			handler := NewHandlers(mockPipeline, mockLLM)
+			handler := NewHandlers(mockPipeline, mockLLM, nil)
 
 			var body []byte
 			var err error
@@ -235,6 +512,364 @@ func TestHandler_IngestHandler(t *testing.T) {
 	}
 }
 
+func TestHandler_IngestFileHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		content     string
+		fields      map[string]string
+		setupMocks  func(pipeline *MockRAGPipeline)
+		wantStatus  int
+		wantID      string
+		wantFormat  string
+		noFileField bool
+	}{
+		{
+			name:     "html file is extracted and named after the file",
+			filename: "pods.html",
+			content:  "<html><body><h1>Pods</h1><script>x()</script><p>A Pod runs containers.</p></body></html>",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					Ingest(gomock.Any(), "Pods\nA Pod runs containers.", "pods.html", map[string]any{"source": "pods.html"}).
+					Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantID:     "pods.html",
+			wantFormat: "html",
+		},
+		{
+			name:     "explicit id and metadata",
+			filename: "flags.csv",
+			content:  "flag,default\n--port,8080\n",
+			fields: map[string]string{
+				"id":       "flags",
+				"metadata": `{"source": "wiki", "tags": ["ops"]}`,
+			},
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					Ingest(gomock.Any(), "flag: --port; default: 8080", "flags", map[string]any{"source": "wiki", "tags": []any{"ops"}}).
+					Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantID:     "flags",
+			wantFormat: "csv",
+		},
+		{
+			name:        "missing file",
+			noFileField: true,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:       "invalid metadata JSON",
+			filename:   "notes.txt",
+			content:    "notes",
+			fields:     map[string]string{"metadata": "[1, 2]"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "binary file",
+			filename:   "image.png",
+			content:    "\x89PNG\r\n\x1a\n\xff\xfe",
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "empty text",
+			filename:   "empty.html",
+			content:    "<html><script>x()</script></html>",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "reserved metadata key",
+			filename: "notes.txt",
+			content:  "notes",
+			fields:   map[string]string{"metadata": `{"doc_id": "x"}`},
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().Ingest(gomock.Any(), "notes", "notes.txt", gomock.Any()).Return(rag.ErrInvalidMetadata)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "ingestion fails",
+			filename: "notes.txt",
+			content:  "notes",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().Ingest(gomock.Any(), "notes", "notes.txt", gomock.Any()).Return(errors.New("ingestion error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockRAGPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline)
+			}
+
+			handler := NewHandlers(mockPipeline, mockLLM, nil)
+
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			if !tt.noFileField {
+				part, err := writer.CreateFormFile("file", tt.filename)
+				if err != nil {
+					t.Fatalf("Failed to create form file: %v", err)
+				}
+				if _, err := part.Write([]byte(tt.content)); err != nil {
+					t.Fatalf("Failed to write form file: %v", err)
+				}
+			}
+			for name, value := range tt.fields {
+				if err := writer.WriteField(name, value); err != nil {
+					t.Fatalf("Failed to write form field: %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Failed to close multipart writer: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/ingest/file", &body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+
+			handler.IngestFileHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("IngestFileHandler() status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				var response map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("IngestFileHandler() invalid JSON response: %v", err)
+				}
+				if response["status"] != "success" || response["id"] != tt.wantID || response["format"] != tt.wantFormat {
+					t.Errorf("IngestFileHandler() response = %v, want success with id %q and format %q", response, tt.wantID, tt.wantFormat)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_ListDocumentsHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		setupMocks   func(*MockRAGPipeline)
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:  "default limit",
+			query: "",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					ListDocuments(gomock.Any(), 20, "").
+					Return(&types.DocumentListResponse{
+						Documents:  []types.Document{{ID: "doc1", ChunkCount: 3}},
+						NextOffset: "next",
+					}, nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `"next_offset":"next"`,
+		},
+		{
+			name:  "limit is capped and offset passed through",
+			query: "?limit=1000&offset=abc",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					ListDocuments(gomock.Any(), 100, "abc").
+					Return(&types.DocumentListResponse{Documents: []types.Document{}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid limit",
+			query:      "?limit=abc",
+			setupMocks: func(*MockRAGPipeline) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "listing fails",
+			query: "",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					ListDocuments(gomock.Any(), 20, "").
+					Return(nil, errors.New("list error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockRAGPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline)
+			}
+
+			router := NewRouter(NewHandlers(mockPipeline, mockLLM, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/documents"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ListDocumentsHandler() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantContains != "" {
+				if !bytes.Contains(w.Body.Bytes(), []byte(tt.wantContains)) {
+					t.Errorf("ListDocumentsHandler() body = %s, want containing %q", w.Body.String(), tt.wantContains)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_GetDocumentHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		docID        string
+		setupMocks   func(*MockRAGPipeline)
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:  "document found",
+			docID: "doc1",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					GetDocument(gomock.Any(), "doc1").
+					Return(&types.Document{
+						ID:         "doc1",
+						ChunkCount: 1,
+						Chunks:     []types.DocumentChunk{{Index: 0, Text: "chunk text"}},
+					}, nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: "chunk text",
+		},
+		{
+			name:  "document not found",
+			docID: "missing",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					GetDocument(gomock.Any(), "missing").
+					Return(nil, rag.ErrDocumentNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:  "lookup fails",
+			docID: "doc1",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					GetDocument(gomock.Any(), "doc1").
+					Return(nil, errors.New("scroll error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockRAGPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline)
+			}
+
+			router := NewRouter(NewHandlers(mockPipeline, mockLLM, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/documents/"+tt.docID, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("GetDocumentHandler() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantContains != "" {
+				if !bytes.Contains(w.Body.Bytes(), []byte(tt.wantContains)) {
+					t.Errorf("GetDocumentHandler() body = %s, want containing %q", w.Body.String(), tt.wantContains)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteDocumentHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		docID      string
+		setupMocks func(*MockRAGPipeline)
+		wantStatus int
+	}{
+		{
+			name:  "successful deletion",
+			docID: "doc1",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					Delete(gomock.Any(), "doc1").
+					Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "deletion fails",
+			docID: "doc1",
+			setupMocks: func(pipeline *MockRAGPipeline) {
+				pipeline.EXPECT().
+					Delete(gomock.Any(), "doc1").
+					Return(errors.New("deletion error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockRAGPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline)
+			}
+
+			router := NewRouter(NewHandlers(mockPipeline, mockLLM, nil))
+
+			req := httptest.NewRequest(http.MethodDelete, "/documents/"+tt.docID, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("DeleteDocumentHandler() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
 func TestErrorResponse(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -310,4 +945,166 @@ func TestHealthHandler(t *testing.T) {
 	}
 }
 
+func TestHandler_QueryHandler_Conversation(t *testing.T) {
+	history := []types.ChatMessage{
+		{Role: types.RoleUser, Content: "What does the --max-connections flag do?"},
+		{Role: types.RoleAssistant, Content: "It limits the number of concurrent client connections."},
+	}
+
+	tests := []struct {
+		name         string
+		requestBody  QueryReq
+		noStore      bool
+		setupMocks   func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore)
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:        "follow-up is condensed for retrieval and answered with history",
+			requestBody: QueryReq{Query: "what about its default value?", ConversationID: "conv-1"},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore) {
+				store.EXPECT().History(gomock.Any(), "conv-1").Return(history, nil)
+				llm.EXPECT().
+					CondenseQuestion(gomock.Any(), history, "what about its default value?").
+					Return("What is the default value of the --max-connections flag?", nil)
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is the default value of the --max-connections flag?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "--max-connections defaults to 100", Score: 0.9}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "what about its default value?", history).
+					Return(&types.Answer{Text: "The default is 100."}, nil)
+				store.EXPECT().Append(gomock.Any(), "conv-1",
+					types.ChatMessage{Role: types.RoleUser, Content: "what about its default value?"},
+					types.ChatMessage{Role: types.RoleAssistant, Content: "The default is 100."},
+				).Return(nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `"conversation_id":"conv-1"`,
+		},
+		{
+			name:        "first turn is not condensed",
+			requestBody: QueryReq{Query: "What is Kubernetes?", ConversationID: "conv-2"},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore) {
+				store.EXPECT().History(gomock.Any(), "conv-2").Return(nil, nil)
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is Kubernetes?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "Kubernetes is a container orchestration system", Score: 0.9}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "What is Kubernetes?", nil).
+					Return(&types.Answer{Text: "A container orchestrator."}, nil)
+				store.EXPECT().Append(gomock.Any(), "conv-2", gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `"answer":"A container orchestrator."`,
+		},
+		{
+			name:        "insufficient context is saved to the conversation",
+			requestBody: QueryReq{Query: "What is the weather on Mars?", ConversationID: "conv-3"},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore) {
+				store.EXPECT().History(gomock.Any(), "conv-3").Return(nil, nil)
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is the weather on Mars?", gomock.Any()).
+					Return(nil, rag.ErrNoRelevantDocuments)
+				store.EXPECT().Append(gomock.Any(), "conv-3",
+					types.ChatMessage{Role: types.RoleUser, Content: "What is the weather on Mars?"},
+					types.ChatMessage{Role: types.RoleAssistant, Content: insufficientContextAnswer},
+				).Return(nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `"insufficient_context":true`,
+		},
+		{
+			name:        "saving failure still returns the answer",
+			requestBody: QueryReq{Query: "What is Kubernetes?", ConversationID: "conv-4"},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore) {
+				store.EXPECT().History(gomock.Any(), "conv-4").Return(nil, nil)
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is Kubernetes?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "Kubernetes is a container orchestration system", Score: 0.9}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "What is Kubernetes?", nil).
+					Return(&types.Answer{Text: "A container orchestrator."}, nil)
+				store.EXPECT().Append(gomock.Any(), "conv-4", gomock.Any(), gomock.Any()).Return(errors.New("disk full"))
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `"answer":"A container orchestrator."`,
+		},
+		{
+			name:        "invalid conversation ID",
+			requestBody: QueryReq{Query: "What is Kubernetes?", ConversationID: "../etc"},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore) {
+				store.EXPECT().History(gomock.Any(), "../etc").Return(nil, conversation.ValidateID("../etc"))
+			},
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "Invalid conversation_id",
+		},
+		{
+			name:        "history load fails",
+			requestBody: QueryReq{Query: "What is Kubernetes?", ConversationID: "conv-5"},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore) {
+				store.EXPECT().History(gomock.Any(), "conv-5").Return(nil, errors.New("read error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "condensation fails",
+			requestBody: QueryReq{Query: "and its default?", ConversationID: "conv-6"},
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient, store *MockHistoryStore) {
+				store.EXPECT().History(gomock.Any(), "conv-6").Return(history, nil)
+				llm.EXPECT().
+					CondenseQuestion(gomock.Any(), history, "and its default?").
+					Return("", errors.New("LLM error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:         "conversations disabled",
+			requestBody:  QueryReq{Query: "What is Kubernetes?", ConversationID: "conv-7"},
+			noStore:      true,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "Conversations are not enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockRAGPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+			mockStore := NewMockHistoryStore(ctrl)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline, mockLLM, mockStore)
+			}
+
+			var historyStore HistoryStore = mockStore
+			if tt.noStore {
+				historyStore = nil
+			}
+			handler := NewHandlers(mockPipeline, mockLLM, historyStore)
+
+			body, err := json.Marshal(tt.requestBody)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
 
+			handler.QueryHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("QueryHandler() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantContains != "" {
+				if !bytes.Contains(w.Body.Bytes(), []byte(tt.wantContains)) {
+					t.Errorf("QueryHandler() body = %s, want containing %q", w.Body.String(), tt.wantContains)
+				}
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"reflect"
 
 	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
 // MockLLMClient is a mock of LLMClient interface.
@@ -33,18 +34,48 @@ func (m *MockLLMClient) EXPECT() *MockLLMClientMockRecorder {
 	return m.recorder
 }
 
-// GenerateAnswer mocks base method.
-func (m *MockLLMClient) GenerateAnswer(ctx context.Context, contextText, question string) (string, error) {
+// CondenseQuestion mocks base method.
+func (m *MockLLMClient) CondenseQuestion(ctx context.Context, history []types.ChatMessage, question string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateAnswer", ctx, contextText, question)
+	ret := m.ctrl.Call(m, "CondenseQuestion", ctx, history, question)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// CondenseQuestion indicates an expected call of CondenseQuestion.
+func (mr *MockLLMClientMockRecorder) CondenseQuestion(ctx, history, question interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CondenseQuestion", reflect.TypeOf((*MockLLMClient)(nil).CondenseQuestion), ctx, history, question)
+}
+
+// GenerateAnswer mocks base method.
+func (m *MockLLMClient) GenerateAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage) (*types.Answer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateAnswer", ctx, contextText, question, history)
+	ret0, _ := ret[0].(*types.Answer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // GenerateAnswer indicates an expected call of GenerateAnswer.
-func (mr *MockLLMClientMockRecorder) GenerateAnswer(ctx, contextText, question interface{}) *gomock.Call {
+func (mr *MockLLMClientMockRecorder) GenerateAnswer(ctx, contextText, question, history interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAnswer", reflect.TypeOf((*MockLLMClient)(nil).GenerateAnswer), ctx, contextText, question, history)
+}
+
+// StreamAnswer mocks base method.
+func (m *MockLLMClient) StreamAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage, onToken func(string) error) (*types.Answer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamAnswer", ctx, contextText, question, history, onToken)
+	ret0, _ := ret[0].(*types.Answer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamAnswer indicates an expected call of StreamAnswer.
+func (mr *MockLLMClientMockRecorder) StreamAnswer(ctx, contextText, question, history, onToken interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAnswer", reflect.TypeOf((*MockLLMClient)(nil).GenerateAnswer), ctx, contextText, question)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamAnswer", reflect.TypeOf((*MockLLMClient)(nil).StreamAnswer), ctx, contextText, question, history, onToken)
 }
 
@@ -8,6 +8,8 @@ import (
 	"reflect"
 
 	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
 // MockRAGPipeline is a mock of RAGPipeline interface.
@@ -33,32 +35,76 @@ func (m *MockRAGPipeline) EXPECT() *MockRAGPipelineMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockRAGPipeline) Delete(ctx context.Context, docID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, docID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRAGPipelineMockRecorder) Delete(ctx, docID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRAGPipeline)(nil).Delete), ctx, docID)
+}
+
+// GetDocument mocks base method.
+func (m *MockRAGPipeline) GetDocument(ctx context.Context, docID string) (*types.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocument", ctx, docID)
+	ret0, _ := ret[0].(*types.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocument indicates an expected call of GetDocument.
+func (mr *MockRAGPipelineMockRecorder) GetDocument(ctx, docID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*MockRAGPipeline)(nil).GetDocument), ctx, docID)
+}
+
 // Ingest mocks base method.
-func (m *MockRAGPipeline) Ingest(ctx context.Context, text string, docID string) error {
+func (m *MockRAGPipeline) Ingest(ctx context.Context, text, docID string, metadata map[string]any) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Ingest", ctx, text, docID)
+	ret := m.ctrl.Call(m, "Ingest", ctx, text, docID, metadata)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Ingest indicates an expected call of Ingest.
-func (mr *MockRAGPipelineMockRecorder) Ingest(ctx, text, docID interface{}) *gomock.Call {
+func (mr *MockRAGPipelineMockRecorder) Ingest(ctx, text, docID, metadata interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ingest", reflect.TypeOf((*MockRAGPipeline)(nil).Ingest), ctx, text, docID, metadata)
+}
+
+// ListDocuments mocks base method.
+func (m *MockRAGPipeline) ListDocuments(ctx context.Context, limit int, offset string) (*types.DocumentListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDocuments", ctx, limit, offset)
+	ret0, _ := ret[0].(*types.DocumentListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDocuments indicates an expected call of ListDocuments.
+func (mr *MockRAGPipelineMockRecorder) ListDocuments(ctx, limit, offset interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ingest", reflect.TypeOf((*MockRAGPipeline)(nil).Ingest), ctx, text, docID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDocuments", reflect.TypeOf((*MockRAGPipeline)(nil).ListDocuments), ctx, limit, offset)
 }
 
 // Retrieve mocks base method.
-func (m *MockRAGPipeline) Retrieve(ctx context.Context, query string) (string, error) {
+func (m *MockRAGPipeline) Retrieve(ctx context.Context, query string, opts rag.RetrieveOptions) ([]rag.SearchResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Retrieve", ctx, query)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Retrieve", ctx, query, opts)
+	ret0, _ := ret[0].([]rag.SearchResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Retrieve indicates an expected call of Retrieve.
-func (mr *MockRAGPipelineMockRecorder) Retrieve(ctx, query interface{}) *gomock.Call {
+func (mr *MockRAGPipelineMockRecorder) Retrieve(ctx, query, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retrieve", reflect.TypeOf((*MockRAGPipeline)(nil).Retrieve), ctx, query)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retrieve", reflect.TypeOf((*MockRAGPipeline)(nil).Retrieve), ctx, query, opts)
 }
 
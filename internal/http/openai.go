@@ -0,0 +1,291 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// ragModelID is the model name advertised by the OpenAI-compatible API
+const ragModelID = "rag"
+
+// finishReasonStop is the finish reason of a completed answer
+var finishReasonStop = "stop"
+
+// ChatCompletionsHandler serves the OpenAI Chat Completions API on top of the RAG pipeline.
+// The last user message is the question; earlier user and assistant messages are the
+// conversation history. Client system messages are ignored in favour of the RAG system prompt.
+// The retrieved chunks are returned in the "sources" extension field.
+func (h *Handler) ChatCompletionsHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req types.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		openAIErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	question, history, ok := splitChatMessages(req.Messages)
+	if !ok {
+		openAIErrorResponse(w, http.StatusBadRequest, "The last message must be a non-empty user message")
+		return
+	}
+
+	if req.KeywordWeight != nil && (*req.KeywordWeight < 0 || *req.KeywordWeight > 1) {
+		openAIErrorResponse(w, http.StatusBadRequest, "keyword_weight must be between 0 and 1")
+		return
+	}
+
+	model := req.Model
+	if model == "" {
+		model = ragModelID
+	}
+
+	ctx := r.Context()
+
+	// Condense a follow-up question into a standalone retrieval query
+	retrievalQuery := question
+	if len(history) > 0 {
+		condensed, err := h.llmClient.CondenseQuestion(ctx, history, question)
+		if err != nil {
+			slog.Error("Error condensing question", "error", err, "query", question)
+			openAIErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to condense question: %v", err))
+			return
+		}
+		retrievalQuery = condensed
+	}
+
+	// RAG pipeline - retrieve relevant context
+	results, err := h.ragPipeline.Retrieve(ctx, retrievalQuery, rag.RetrieveOptions{
+		KeywordWeight: req.KeywordWeight,
+		Filter:        req.Filter,
+	})
+	insufficientContext := errors.Is(err, rag.ErrNoRelevantDocuments)
+	if err != nil && !insufficientContext {
+		slog.Error("Error retrieving context", "error", err, "query", retrievalQuery)
+		openAIErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to retrieve context: %v", err))
+		return
+	}
+
+	completion := types.ChatCompletionResponse{
+		ID:      newCompletionID(),
+		Created: time.Now().Unix(),
+		Model:   model,
+		Sources: newChatCompletionSources(results),
+	}
+
+	if req.Stream {
+		h.streamChatCompletion(ctx, w, req, completion, results, question, history, insufficientContext)
+		return
+	}
+
+	// Nothing to ground an answer on, skip generation
+	answer := &types.Answer{Text: insufficientContextAnswer}
+	if !insufficientContext {
+		answer, err = h.llmClient.GenerateAnswer(ctx, rag.BuildContext(results), question, history)
+		if err != nil {
+			slog.Error("Error generating answer", "error", err, "query", question)
+			openAIErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to generate answer: %v", err))
+			return
+		}
+	}
+
+	completion.Object = "chat.completion"
+	completion.Choices = []types.ChatCompletionChoice{{
+		Message:      &types.ChatCompletionDelta{Role: types.RoleAssistant, Content: answer.Text},
+		FinishReason: &finishReasonStop,
+	}}
+	completion.Usage = &answer.Usage
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(completion); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+// streamChatCompletion streams the answer as chat completion chunks: a chunk with the assistant
+// role, a chunk per generated token, a final chunk with the finish reason and sources, an optional
+// usage chunk and the [DONE] marker
+func (h *Handler) streamChatCompletion(ctx context.Context, w http.ResponseWriter, req types.ChatCompletionRequest, completion types.ChatCompletionResponse, results []rag.SearchResult, question string, history []types.ChatMessage, insufficientContext bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		openAIErrorResponse(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	completion.Object = "chat.completion.chunk"
+	sources := completion.Sources
+	completion.Sources = nil
+
+	writeChunk := func(delta *types.ChatCompletionDelta, finishReason *string) error {
+		chunk := completion
+		chunk.Choices = []types.ChatCompletionChoice{{Delta: delta, FinishReason: finishReason}}
+		if finishReason != nil {
+			chunk.Sources = sources
+		}
+		return writeData(w, flusher, chunk)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeChunk(&types.ChatCompletionDelta{Role: types.RoleAssistant}, nil); err != nil {
+		slog.Error("Error writing chunk", "error", err)
+		return
+	}
+
+	// Nothing to ground an answer on, skip generation
+	answer := &types.Answer{Text: insufficientContextAnswer}
+	if insufficientContext {
+		if err := writeChunk(&types.ChatCompletionDelta{Content: answer.Text}, nil); err != nil {
+			slog.Error("Error writing chunk", "error", err)
+			return
+		}
+	} else {
+		// LLM generation, forwarding tokens as they arrive
+		var err error
+		answer, err = h.llmClient.StreamAnswer(ctx, rag.BuildContext(results), question, history, func(token string) error {
+			return writeChunk(&types.ChatCompletionDelta{Content: token}, nil)
+		})
+		if err != nil {
+			slog.Error("Error generating answer", "error", err, "query", question)
+			if err := writeData(w, flusher, types.OpenAIErrorResponse{Error: types.OpenAIError{
+				Message: fmt.Sprintf("Failed to generate answer: %v", err),
+				Type:    "server_error",
+			}}); err != nil {
+				slog.Error("Error writing error chunk", "error", err)
+			}
+			return
+		}
+	}
+
+	if err := writeChunk(&types.ChatCompletionDelta{}, &finishReasonStop); err != nil {
+		slog.Error("Error writing chunk", "error", err)
+		return
+	}
+
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		usageChunk := completion
+		usageChunk.Choices = []types.ChatCompletionChoice{}
+		usageChunk.Usage = &answer.Usage
+		if err := writeData(w, flusher, usageChunk); err != nil {
+			slog.Error("Error writing usage chunk", "error", err)
+			return
+		}
+	}
+
+	if _, err := fmt.Fprint(w, "data: [DONE]\n\n"); err != nil {
+		slog.Error("Error writing done marker", "error", err)
+		return
+	}
+	flusher.Flush()
+}
+
+// ModelsHandler lists the single model served by the OpenAI-compatible API,
+// so that clients can discover it
+func (h *Handler) ModelsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.ModelList{
+		Object: "list",
+		Data: []types.ModelInfo{{
+			ID:      ragModelID,
+			Object:  "model",
+			OwnedBy: "ya-practicum-go-and-llm",
+		}},
+	}); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+// splitChatMessages returns the last user message as the question and the earlier user and
+// assistant messages as the history. ok is false if the last message is not a non-empty user message.
+func splitChatMessages(messages []types.ChatCompletionMessage) (question string, history []types.ChatMessage, ok bool) {
+	if len(messages) == 0 {
+		return "", nil, false
+	}
+
+	last := messages[len(messages)-1]
+	if last.Role != types.RoleUser || last.Content == "" {
+		return "", nil, false
+	}
+
+	for _, msg := range messages[:len(messages)-1] {
+		if msg.Role != types.RoleUser && msg.Role != types.RoleAssistant {
+			continue
+		}
+		history = append(history, types.ChatMessage{Role: msg.Role, Content: string(msg.Content)})
+	}
+
+	return string(last.Content), history, true
+}
+
+// newChatCompletionSources converts retrieved chunks into the sources extension field
+func newChatCompletionSources(results []rag.SearchResult) []types.ChatCompletionSource {
+	sources := make([]types.ChatCompletionSource, 0, len(results))
+	for _, result := range results {
+		sources = append(sources, types.ChatCompletionSource{
+			Source: types.Source{
+				DocID:       result.DocID,
+				ChunkIndex:  result.ChunkIndex,
+				Score:       result.Score,
+				HeadingPath: result.HeadingPath,
+				Offsets:     result.Offsets,
+				FilePath:    result.FilePath,
+				Symbol:      result.Symbol,
+				StartLine:   result.StartLine,
+				EndLine:     result.EndLine,
+			},
+			Text: result.Text,
+		})
+	}
+	return sources
+}
+
+// newCompletionID returns a random chat completion ID
+func newCompletionID() string {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	}
+	return "chatcmpl-" + hex.EncodeToString(b[:])
+}
+
+// writeData writes a single data-only Server-Sent Event, as used by the OpenAI streaming API
+func writeData(w http.ResponseWriter, flusher http.Flusher, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", payload); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	flusher.Flush()
+	return nil
+}
+
+// openAIErrorResponse writes an error in the OpenAI wire format, which OpenAI clients can display
+func openAIErrorResponse(w http.ResponseWriter, status int, message string) {
+	errorType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errorType = "server_error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(types.OpenAIErrorResponse{
+		Error: types.OpenAIError{Message: message, Type: errorType},
+	}); err != nil {
+		slog.Error("Error encoding error response", "error", err, "status", status)
+	}
+}
@@ -0,0 +1,251 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+func TestHandler_ChatCompletionsHandler(t *testing.T) {
+	history := []types.ChatMessage{
+		{Role: types.RoleUser, Content: "What does the --max-connections flag do?"},
+		{Role: types.RoleAssistant, Content: "It limits concurrent connections."},
+	}
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		setupMocks   func(pipeline *MockRAGPipeline, llm *MockLLMClient)
+		wantStatus   int
+		wantContains []string
+	}{
+		{
+			name:        "answers the last user message with sources",
+			requestBody: `{"model": "rag", "messages": [{"role": "system", "content": "You are helpful."}, {"role": "user", "content": "What is Kubernetes?"}]}`,
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is Kubernetes?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "Kubernetes is a container orchestration system", Score: 0.9, DocID: "k8s", ChunkIndex: 1}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "What is Kubernetes?", nil).
+					Return(&types.Answer{Text: "A container orchestrator.", Usage: types.TokenUsage{TotalTokens: 42}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				`"object":"chat.completion"`,
+				`"model":"rag"`,
+				`"message":{"role":"assistant","content":"A container orchestrator."},"finish_reason":"stop"`,
+				`"total_tokens":42`,
+				`"sources":[{"doc_id":"k8s","chunk_index":1,"score":0.9,"text":"Kubernetes is a container orchestration system"}]`,
+			},
+		},
+		{
+			name:        "earlier messages are history and the question is condensed",
+			requestBody: `{"model": "rag", "messages": [{"role": "user", "content": "What does the --max-connections flag do?"}, {"role": "assistant", "content": "It limits concurrent connections."}, {"role": "user", "content": [{"type": "text", "text": "what about its default value?"}]}]}`,
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				llm.EXPECT().
+					CondenseQuestion(gomock.Any(), history, "what about its default value?").
+					Return("What is the default value of --max-connections?", nil)
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is the default value of --max-connections?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "--max-connections defaults to 100", Score: 0.8}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "what about its default value?", history).
+					Return(&types.Answer{Text: "100"}, nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{`"content":"100"`},
+		},
+		{
+			name:        "insufficient context skips generation",
+			requestBody: `{"messages": [{"role": "user", "content": "What is the weather on Mars?"}]}`,
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is the weather on Mars?", gomock.Any()).
+					Return(nil, rag.ErrNoRelevantDocuments)
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{insufficientContextAnswer},
+		},
+		{
+			name:         "last message is not from the user",
+			requestBody:  `{"messages": [{"role": "user", "content": "hi"}, {"role": "assistant", "content": "hello"}]}`,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: []string{`"type":"invalid_request_error"`},
+		},
+		{
+			name:         "no messages",
+			requestBody:  `{"model": "rag", "messages": []}`,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: []string{`"type":"invalid_request_error"`},
+		},
+		{
+			name:         "invalid content",
+			requestBody:  `{"messages": [{"role": "user", "content": 42}]}`,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: []string{"Invalid request body"},
+		},
+		{
+			name:        "LLM generation fails",
+			requestBody: `{"messages": [{"role": "user", "content": "test query"}]}`,
+			setupMocks: func(pipeline *MockRAGPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "test query", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "context text", Score: 0.5}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "test query", nil).
+					Return(nil, errors.New("LLM error"))
+			},
+			wantStatus:   http.StatusInternalServerError,
+			wantContains: []string{`"type":"server_error"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockRAGPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline, mockLLM)
+			}
+
+			router := NewRouter(NewHandlers(mockPipeline, mockLLM, nil))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ChatCompletionsHandler() status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			for _, want := range tt.wantContains {
+				if !bytes.Contains(w.Body.Bytes(), []byte(want)) {
+					t.Errorf("ChatCompletionsHandler() body = %s, want containing %q", w.Body.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_ChatCompletionsHandler_Stream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPipeline := NewMockRAGPipeline(ctrl)
+	mockLLM := NewMockLLMClient(ctrl)
+
+	mockPipeline.EXPECT().
+		Retrieve(gomock.Any(), "What is Kubernetes?", gomock.Any()).
+		Return([]rag.SearchResult{{Text: "Kubernetes is a container orchestration system", Score: 0.9, DocID: "k8s"}}, nil)
+	mockLLM.EXPECT().
+		StreamAnswer(gomock.Any(), gomock.Any(), "What is Kubernetes?", nil, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, contextText, question string, history []types.ChatMessage, onToken func(string) error) (*types.Answer, error) {
+			for _, token := range []string{"Kuber", "netes"} {
+				if err := onToken(token); err != nil {
+					return nil, err
+				}
+			}
+			return &types.Answer{Text: "Kubernetes", Usage: types.TokenUsage{TotalTokens: 7}}, nil
+		})
+
+	router := NewRouter(NewHandlers(mockPipeline, mockLLM, nil))
+
+	body := `{"model": "rag", "stream": true, "stream_options": {"include_usage": true}, "messages": [{"role": "user", "content": "What is Kubernetes?"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ChatCompletionsHandler() status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("ChatCompletionsHandler() Content-Type = %q, want text/event-stream", got)
+	}
+
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	if len(events) != 6 {
+		t.Fatalf("ChatCompletionsHandler() got %d events, want 6: %s", len(events), w.Body.String())
+	}
+	if events[len(events)-1] != "data: [DONE]" {
+		t.Errorf("ChatCompletionsHandler() last event = %q, want the [DONE] marker", events[len(events)-1])
+	}
+
+	var chunks []types.ChatCompletionResponse
+	for _, event := range events[:len(events)-1] {
+		data, ok := strings.CutPrefix(event, "data: ")
+		if !ok {
+			t.Fatalf("ChatCompletionsHandler() event = %q, want data-only event", event)
+		}
+		var chunk types.ChatCompletionResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("ChatCompletionsHandler() chunk %q is not JSON: %v", data, err)
+		}
+		if chunk.Object != "chat.completion.chunk" || !strings.HasPrefix(chunk.ID, "chatcmpl-") {
+			t.Errorf("ChatCompletionsHandler() chunk = %+v, want a chat.completion.chunk", chunk)
+		}
+		if len(chunks) > 0 && chunk.ID != chunks[0].ID {
+			t.Errorf("ChatCompletionsHandler() chunk ID = %q, want %q as in the first chunk", chunk.ID, chunks[0].ID)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if role := chunks[0].Choices[0].Delta.Role; role != types.RoleAssistant {
+		t.Errorf("ChatCompletionsHandler() first delta role = %q, want assistant", role)
+	}
+	var content string
+	for _, chunk := range chunks[1:3] {
+		content += chunk.Choices[0].Delta.Content
+	}
+	if content != "Kubernetes" {
+		t.Errorf("ChatCompletionsHandler() streamed content = %q, want %q", content, "Kubernetes")
+	}
+	final := chunks[3]
+	if final.Choices[0].FinishReason == nil || *final.Choices[0].FinishReason != "stop" {
+		t.Errorf("ChatCompletionsHandler() final chunk = %+v, want finish_reason stop", final.Choices[0])
+	}
+	if len(final.Sources) != 1 || final.Sources[0].DocID != "k8s" {
+		t.Errorf("ChatCompletionsHandler() final chunk sources = %+v, want the retrieved chunk", final.Sources)
+	}
+	if usage := chunks[4]; len(usage.Choices) != 0 || usage.Usage == nil || usage.Usage.TotalTokens != 7 {
+		t.Errorf("ChatCompletionsHandler() usage chunk = %+v, want usage without choices", usage)
+	}
+}
+
+func TestHandler_ModelsHandler(t *testing.T) {
+	router := NewRouter(NewHandlers(nil, nil, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ModelsHandler() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var models types.ModelList
+	if err := json.NewDecoder(w.Body).Decode(&models); err != nil {
+		t.Fatalf("ModelsHandler() body is not JSON: %v", err)
+	}
+	if len(models.Data) != 1 || models.Data[0].ID != ragModelID {
+		t.Errorf("ModelsHandler() = %+v, want the %q model", models, ragModelID)
+	}
+}
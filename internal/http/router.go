@@ -16,9 +16,18 @@ func NewRouter(handler *Handler) *chi.Mux {
 
 	// Routes
 	r.Post("/query", handler.QueryHandler)
+	r.Post("/query/stream", handler.QueryStreamHandler)
 	r.Post("/ingest", handler.IngestHandler)
+	r.Post("/ingest/file", handler.IngestFileHandler)
+	r.Get("/documents", handler.ListDocumentsHandler)
+	r.Get("/documents/{id}", handler.GetDocumentHandler)
+	r.Delete("/documents/{id}", handler.DeleteDocumentHandler)
 	r.Get("/health", HealthHandler)
 
+	// OpenAI-compatible API
+	r.Post("/v1/chat/completions", handler.ChatCompletionsHandler)
+	r.Get("/v1/models", handler.ModelsHandler)
+
 	return r
 }
 
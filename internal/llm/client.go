@@ -1,23 +1,195 @@
 package llm
 
 import (
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
-// Client wraps OpenAI client and provides RAG-specific methods
+// defaultEmbedBatchSize is used when a non-positive embedding batch size is configured
+const defaultEmbedBatchSize = 100
+
+// answerTemperature is the sampling temperature used for answer generation
+const answerTemperature = 0.7
+
+// knownEmbedDimensions maps OpenAI embedding models to their native output dimension.
+// Other providers may serve a different model under the same name, so they are always probed.
+var knownEmbedDimensions = map[string]int{
+	"text-embedding-3-large": 3072,
+	"text-embedding-3-small": 1536,
+	"text-embedding-ada-002": 1536,
+}
+
+// Client wraps an LLM provider and provides RAG-specific methods
 type Client struct {
-	client     *openai.Client
-	model      string
-	embedModel string
+	provider        Provider
+	providerName    string
+	model           string
+	embedModel      string
+	embedBatchSize  int
+	embedDimensions int
 }
 
-// NewClient creates a new LLM client with API key
-func NewClient(apiKey, model, embedModel string) *Client {
-	client := openai.NewClient(option.WithAPIKey(apiKey))
+// NewClient creates a new LLM client on top of the given provider, named as in ProviderConfig.
+// embedBatchSize limits how many texts are sent in a single embeddings request.
+// embedDimensions, if positive, asks the provider to shorten embeddings to that dimension.
+func NewClient(provider Provider, providerName, model, embedModel string, embedBatchSize, embedDimensions int) *Client {
+	if embedBatchSize <= 0 {
+		embedBatchSize = defaultEmbedBatchSize
+	}
 	return &Client{
-		client:     &client,
-		model:      model,
-		embedModel: embedModel,
+		provider:        provider,
+		providerName:    providerName,
+		model:           model,
+		embedModel:      embedModel,
+		embedBatchSize:  embedBatchSize,
+		embedDimensions: embedDimensions,
+	}
+}
+
+// EmbeddingDimension returns the dimension of the embeddings produced by the client.
+// It uses the configured dimensions or, for the OpenAI provider, a known model dimension,
+// and otherwise probes the provider.
+func (c *Client) EmbeddingDimension(ctx context.Context) (int, error) {
+	if c.embedDimensions > 0 {
+		return c.embedDimensions, nil
+	}
+	if c.providerName == ProviderOpenAI || c.providerName == "" {
+		if dim, ok := knownEmbedDimensions[c.embedModel]; ok {
+			return dim, nil
+		}
+	}
+
+	embedding, err := c.GenerateEmbedding(ctx, "dimension probe")
+	if err != nil {
+		return 0, fmt.Errorf("failed to probe embedding dimension: %w", err)
+	}
+	return len(embedding), nil
+}
+
+// GenerateAnswer generates an answer using the LLM with context.
+// history holds the earlier turns of the conversation, oldest first, and may be empty.
+func (c *Client) GenerateAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage) (*types.Answer, error) {
+	return c.provider.Complete(ctx, ChatRequest{
+		Model:       c.model,
+		Messages:    buildAnswerMessages(contextText, question, history),
+		Temperature: answerTemperature,
+	})
+}
+
+// StreamAnswer generates an answer using the LLM with context, calling onToken for every
+// content delta as it arrives. The returned answer holds the full text and usage.
+func (c *Client) StreamAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage, onToken func(token string) error) (*types.Answer, error) {
+	return c.provider.StreamComplete(ctx, ChatRequest{
+		Model:       c.model,
+		Messages:    buildAnswerMessages(contextText, question, history),
+		Temperature: answerTemperature,
+	}, onToken)
+}
+
+// GenerateEmbedding generates an embedding for the given text
+func (c *Client) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.provider.Embed(ctx, EmbeddingRequest{
+		Model:      c.embedModel,
+		Input:      []string{text},
+		Dimensions: c.embedDimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings generates embeddings for multiple texts, sending them in batches.
+// The returned embeddings are in the same order as the input texts.
+func (c *Client) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+
+	for start := 0; start < len(texts); start += c.embedBatchSize {
+		end := min(start+c.embedBatchSize, len(texts))
+		batch := texts[start:end]
+
+		batchEmbeddings, err := c.provider.Embed(ctx, EmbeddingRequest{
+			Model:      c.embedModel,
+			Input:      batch,
+			Dimensions: c.embedDimensions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embeddings for batch starting at %d: %w", start, err)
+		}
+
+		if len(batchEmbeddings) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings for batch starting at %d, got %d", len(batch), start, len(batchEmbeddings))
+		}
+
+		embeddings = append(embeddings, batchEmbeddings...)
+	}
+
+	return embeddings, nil
+}
+
+// buildAnswerMessages builds the system message, the prior conversation turns and the user
+// message for answering a question with context
+func buildAnswerMessages(contextText, question string, history []types.ChatMessage) []Message {
+	systemPrompt := findPrompt("system_prompt.txt", "Ты - помощник, который отвечает на вопросы на основе предоставленного контекста.\nОтвечай точно и по делу, используя только информацию из контекста.\nЕсли в контексте нет информации для ответа, скажи об этом.")
+
+	answerPromptTemplate := findPrompt("answer_prompt.txt", "Используй контекст ниже, чтобы ответить на вопрос.\n\nКонтекст:\n{context}\n\nВопрос: {question}\n\nДай точный технический ответ на основе предоставленного контекста.")
+
+	// Replace placeholders
+	answerPrompt := strings.ReplaceAll(answerPromptTemplate, "{context}", contextText)
+	answerPrompt = strings.ReplaceAll(answerPrompt, "{question}", question)
+
+	messages := make([]Message, 0, len(history)+2)
+	messages = append(messages, Message{Role: RoleSystem, Content: systemPrompt})
+	messages = append(messages, historyMessages(history)...)
+	messages = append(messages, Message{Role: RoleUser, Content: answerPrompt})
+
+	return messages
+}
+
+// historyMessages converts conversation turns into chat messages. Prior questions are sent
+// as asked, without the retrieved context, to keep the prompt small.
+func historyMessages(history []types.ChatMessage) []Message {
+	messages := make([]Message, 0, len(history))
+	for _, msg := range history {
+		role := RoleUser
+		if msg.Role == types.RoleAssistant {
+			role = RoleAssistant
+		}
+		messages = append(messages, Message{Role: role, Content: msg.Content})
+	}
+	return messages
+}
+
+// findPrompt loads a prompt file from the prompts directory, with fallback to a default
+func findPrompt(name, fallback string) string {
+	// Try multiple possible paths
+	promptPaths := []string{
+		"prompts/" + name,
+		"./prompts/" + name,
+		"../prompts/" + name,
+	}
+	for _, path := range promptPaths {
+		if p, err := loadPrompt(path); err == nil {
+			return p
+		}
+	}
+	return fallback
+}
+
+// loadPrompt loads a prompt from a file
+func loadPrompt(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
 	}
+	return strings.TrimSpace(string(data)), nil
 }
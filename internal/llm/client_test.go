@@ -0,0 +1,394 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+func TestClient_GenerateEmbeddings(t *testing.T) {
+	tests := []struct {
+		name        string
+		batchSize   int
+		texts       []string
+		setupMocks  func(*MockProvider)
+		wantErr     bool
+		errContains string
+		want        [][]float32
+	}{
+		{
+			name:      "splits into batches and preserves order",
+			batchSize: 2,
+			texts:     []string{"a", "b", "c"},
+			setupMocks: func(p *MockProvider) {
+				gomock.InOrder(
+					p.EXPECT().Embed(gomock.Any(), EmbeddingRequest{Model: "embed", Input: []string{"a", "b"}}).
+						Return([][]float32{{1}, {2}}, nil),
+					p.EXPECT().Embed(gomock.Any(), EmbeddingRequest{Model: "embed", Input: []string{"c"}}).
+						Return([][]float32{{3}}, nil),
+				)
+			},
+			want: [][]float32{{1}, {2}, {3}},
+		},
+		{
+			name:      "batch fails",
+			batchSize: 2,
+			texts:     []string{"a", "b", "c"},
+			setupMocks: func(p *MockProvider) {
+				p.EXPECT().Embed(gomock.Any(), gomock.Any()).Return([][]float32{{1}, {2}}, nil)
+				p.EXPECT().Embed(gomock.Any(), gomock.Any()).Return(nil, errors.New("API error"))
+			},
+			wantErr:     true,
+			errContains: "batch starting at 2",
+		},
+		{
+			name:      "provider returns too few embeddings",
+			batchSize: 10,
+			texts:     []string{"a", "b"},
+			setupMocks: func(p *MockProvider) {
+				p.EXPECT().Embed(gomock.Any(), gomock.Any()).Return([][]float32{{1}}, nil)
+			},
+			wantErr:     true,
+			errContains: "expected 2 embeddings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockProvider := NewMockProvider(ctrl)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockProvider)
+			}
+
+			client := NewClient(mockProvider, ProviderOpenAI, "chat", "embed", tt.batchSize, 0)
+			got, err := client.GenerateEmbeddings(context.Background(), tt.texts)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GenerateEmbeddings() expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("GenerateEmbeddings() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("GenerateEmbeddings() unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("GenerateEmbeddings() returned %d embeddings, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i][0] != tt.want[i][0] {
+					t.Errorf("GenerateEmbeddings()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_EmbeddingDimension(t *testing.T) {
+	tests := []struct {
+		name            string
+		provider        string
+		embedModel      string
+		embedDimensions int
+		setupMocks      func(*MockProvider)
+		want            int
+		wantErr         bool
+	}{
+		{
+			name:            "configured dimensions win",
+			provider:        ProviderOllama,
+			embedModel:      "text-embedding-3-large",
+			embedDimensions: 256,
+			want:            256,
+		},
+		{
+			name:       "known model",
+			provider:   ProviderOpenAI,
+			embedModel: "text-embedding-3-small",
+			want:       1536,
+		},
+		{
+			name:       "known model name on another provider is probed",
+			provider:   ProviderOpenAICompatible,
+			embedModel: "text-embedding-3-small",
+			setupMocks: func(p *MockProvider) {
+				p.EXPECT().Embed(gomock.Any(), gomock.Any()).Return([][]float32{make([]float32, 512)}, nil)
+			},
+			want: 512,
+		},
+		{
+			name:       "unknown model is probed",
+			provider:   ProviderOpenAI,
+			embedModel: "nomic-embed-text",
+			setupMocks: func(p *MockProvider) {
+				p.EXPECT().Embed(gomock.Any(), gomock.Any()).Return([][]float32{make([]float32, 768)}, nil)
+			},
+			want: 768,
+		},
+		{
+			name:       "probe fails",
+			provider:   ProviderOllama,
+			embedModel: "nomic-embed-text",
+			setupMocks: func(p *MockProvider) {
+				p.EXPECT().Embed(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockProvider := NewMockProvider(ctrl)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockProvider)
+			}
+
+			client := NewClient(mockProvider, tt.provider, "chat", tt.embedModel, 0, tt.embedDimensions)
+			got, err := client.EmbeddingDimension(context.Background())
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("EmbeddingDimension() expected error but got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("EmbeddingDimension() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EmbeddingDimension() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_GenerateAnswer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := NewMockProvider(ctrl)
+	mockProvider.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req ChatRequest) (*types.Answer, error) {
+			if req.Model != "chat" {
+				t.Errorf("Complete() model = %q, want %q", req.Model, "chat")
+			}
+			if len(req.Messages) != 2 || req.Messages[0].Role != RoleSystem || req.Messages[1].Role != RoleUser {
+				t.Fatalf("Complete() messages = %+v, want system and user message", req.Messages)
+			}
+			if !strings.Contains(req.Messages[1].Content, "context text") || !strings.Contains(req.Messages[1].Content, "question text") {
+				t.Errorf("Complete() user message = %q, want context and question", req.Messages[1].Content)
+			}
+			return &types.Answer{Text: "answer"}, nil
+		},
+	)
+
+	client := NewClient(mockProvider, ProviderOpenAI, "chat", "embed", 0, 0)
+	answer, err := client.GenerateAnswer(context.Background(), "context text", "question text", nil)
+	if err != nil {
+		t.Fatalf("GenerateAnswer() unexpected error: %v", err)
+	}
+	if answer.Text != "answer" {
+		t.Errorf("GenerateAnswer() = %q, want %q", answer.Text, "answer")
+	}
+}
+
+func TestClient_GenerateAnswerWithHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	history := []types.ChatMessage{
+		{Role: types.RoleUser, Content: "What does --max-connections do?"},
+		{Role: types.RoleAssistant, Content: "It limits concurrent connections."},
+	}
+
+	mockProvider := NewMockProvider(ctrl)
+	mockProvider.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req ChatRequest) (*types.Answer, error) {
+			wantRoles := []string{RoleSystem, RoleUser, RoleAssistant, RoleUser}
+			if len(req.Messages) != len(wantRoles) {
+				t.Fatalf("Complete() messages = %+v, want %d messages", req.Messages, len(wantRoles))
+			}
+			for i, role := range wantRoles {
+				if req.Messages[i].Role != role {
+					t.Errorf("Complete() message %d role = %q, want %q", i, req.Messages[i].Role, role)
+				}
+			}
+			if req.Messages[1].Content != history[0].Content || req.Messages[2].Content != history[1].Content {
+				t.Errorf("Complete() history messages = %+v, want %+v", req.Messages[1:3], history)
+			}
+			if !strings.Contains(req.Messages[3].Content, "what about its default value?") {
+				t.Errorf("Complete() user message = %q, want the follow-up question", req.Messages[3].Content)
+			}
+			return &types.Answer{Text: "answer"}, nil
+		},
+	)
+
+	client := NewClient(mockProvider, ProviderOpenAI, "chat", "embed", 0, 0)
+	if _, err := client.GenerateAnswer(context.Background(), "context text", "what about its default value?", history); err != nil {
+		t.Fatalf("GenerateAnswer() unexpected error: %v", err)
+	}
+}
+
+func TestClient_CondenseQuestion(t *testing.T) {
+	history := []types.ChatMessage{
+		{Role: types.RoleUser, Content: "What does the --max-connections flag do?"},
+		{Role: types.RoleAssistant, Content: "It limits the number of concurrent client connections."},
+	}
+
+	tests := []struct {
+		name       string
+		history    []types.ChatMessage
+		completion string
+		wantCall   bool
+		want       string
+	}{
+		{
+			name:       "follow-up is rewritten",
+			history:    history,
+			completion: "What is the default value of the --max-connections flag?",
+			wantCall:   true,
+			want:       "What is the default value of the --max-connections flag?",
+		},
+		{
+			name:       "quotes are stripped",
+			history:    history,
+			completion: " \"What is the default value of the --max-connections flag?\"\n",
+			wantCall:   true,
+			want:       "What is the default value of the --max-connections flag?",
+		},
+		{
+			name:       "empty completion keeps the question",
+			history:    history,
+			completion: "",
+			wantCall:   true,
+			want:       "what about its default value?",
+		},
+		{
+			name: "no history skips the LLM",
+			want: "what about its default value?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockProvider := NewMockProvider(ctrl)
+			if tt.wantCall {
+				mockProvider.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, req ChatRequest) (*types.Answer, error) {
+						if req.Temperature != 0 {
+							t.Errorf("Complete() temperature = %v, want 0", req.Temperature)
+						}
+						prompt := req.Messages[len(req.Messages)-1].Content
+						if !strings.Contains(prompt, "--max-connections") || !strings.Contains(prompt, "what about its default value?") {
+							t.Errorf("Complete() prompt = %q, want history and question", prompt)
+						}
+						return &types.Answer{Text: tt.completion}, nil
+					},
+				)
+			}
+
+			client := NewClient(mockProvider, ProviderOpenAI, "chat", "embed", 0, 0)
+			got, err := client.CondenseQuestion(context.Background(), tt.history, "what about its default value?")
+			if err != nil {
+				t.Fatalf("CondenseQuestion() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CondenseQuestion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ScoreRelevance(t *testing.T) {
+	tests := []struct {
+		name        string
+		completion  string
+		want        []float64
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:       "plain array",
+			completion: "[8, 0.5, 3]",
+			want:       []float64{8, 0.5, 3},
+		},
+		{
+			name:       "array in code fence",
+			completion: "```json\n[1, 2, 3]\n```",
+			want:       []float64{1, 2, 3},
+		},
+		{
+			name:        "wrong number of scores",
+			completion:  "[1, 2]",
+			wantErr:     true,
+			errContains: "expected 3 relevance scores",
+		},
+		{
+			name:        "no array",
+			completion:  "All passages are relevant.",
+			wantErr:     true,
+			errContains: "failed to parse relevance scores",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockProvider := NewMockProvider(ctrl)
+			mockProvider.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, req ChatRequest) (*types.Answer, error) {
+					if !strings.Contains(req.Messages[0].Content, "[3]\nthird") {
+						t.Errorf("Complete() prompt = %q, want numbered passages", req.Messages[0].Content)
+					}
+					return &types.Answer{Text: tt.completion}, nil
+				},
+			)
+
+			client := NewClient(mockProvider, ProviderOpenAI, "chat", "embed", 0, 0)
+			got, err := client.ScoreRelevance(context.Background(), "question", []string{"first", "second", "third"})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ScoreRelevance() expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("ScoreRelevance() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ScoreRelevance() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ScoreRelevance() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ScoreRelevance() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
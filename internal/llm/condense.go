@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// condenseTemperature keeps question rewriting deterministic
+const condenseTemperature = 0
+
+// CondenseQuestion rewrites a follow-up question into a standalone question using the
+// conversation history, so that it can be used as a retrieval query on its own.
+// Without history the question is returned unchanged.
+func (c *Client) CondenseQuestion(ctx context.Context, history []types.ChatMessage, question string) (string, error) {
+	if len(history) == 0 {
+		return question, nil
+	}
+
+	answer, err := c.provider.Complete(ctx, ChatRequest{
+		Model:       c.model,
+		Messages:    buildCondenseMessages(history, question),
+		Temperature: condenseTemperature,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to condense question: %w", err)
+	}
+
+	standalone := strings.Trim(strings.TrimSpace(answer.Text), "\"«»")
+	if standalone == "" {
+		return question, nil
+	}
+	return standalone, nil
+}
+
+// buildCondenseMessages builds the user message asking to rewrite a follow-up question
+func buildCondenseMessages(history []types.ChatMessage, question string) []Message {
+	promptTemplate := findPrompt("condense_prompt.txt", "Ниже приведена история диалога и последующий вопрос пользователя. Переформулируй последующий вопрос в самостоятельный вопрос, понятный без истории.\n\nИстория диалога:\n{history}\n\nПоследующий вопрос: {question}\n\nВерни только самостоятельный вопрос, без пояснений.")
+
+	var historyBuilder strings.Builder
+	for _, msg := range history {
+		speaker := "Пользователь"
+		if msg.Role == types.RoleAssistant {
+			speaker = "Ассистент"
+		}
+		fmt.Fprintf(&historyBuilder, "%s: %s\n", speaker, msg.Content)
+	}
+
+	prompt := strings.ReplaceAll(promptTemplate, "{history}", strings.TrimSpace(historyBuilder.String()))
+	prompt = strings.ReplaceAll(prompt, "{question}", question)
+
+	return []Message{
+		{Role: RoleUser, Content: prompt},
+	}
+}
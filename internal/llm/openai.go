@@ -3,103 +3,156 @@ package llm
 import (
 	"context"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/packages/param"
 	"github.com/openai/openai-go/shared"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
-// GenerateAnswer generates an answer using the LLM with context
-func (c *Client) GenerateAnswer(ctx context.Context, contextText, question string) (string, error) {
-	// Try to load prompts, with fallback to defaults
-	systemPrompt := "Ты - помощник, который отвечает на вопросы на основе предоставленного контекста.\nОтвечай точно и по делу, используя только информацию из контекста.\nЕсли в контексте нет информации для ответа, скажи об этом."
-
-	// Try multiple possible paths
-	promptPaths := []string{
-		"prompts/system_prompt.txt",
-		"./prompts/system_prompt.txt",
-		"../prompts/system_prompt.txt",
-	}
-	for _, path := range promptPaths {
-		if p, err := loadPrompt(path); err == nil {
-			systemPrompt = p
-			break
-		}
+// OpenAIProvider implements Provider on top of the OpenAI API or any server
+// speaking the same wire format (Ollama, llama.cpp, vLLM)
+type OpenAIProvider struct {
+	client *openai.Client
+}
+
+// NewOpenAIProvider creates a provider for the OpenAI API.
+// An empty baseURL targets api.openai.com; an empty apiKey is allowed for keyless local servers.
+func NewOpenAIProvider(apiKey, baseURL string) *OpenAIProvider {
+	var opts []option.RequestOption
+	if apiKey != "" {
+		opts = append(opts, option.WithAPIKey(apiKey))
+	}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	client := openai.NewClient(opts...)
+	return &OpenAIProvider{
+		client: &client,
 	}
+}
 
-	answerPromptTemplate := "Используй контекст ниже, чтобы ответить на вопрос.\n\nКонтекст:\n{context}\n\nВопрос: {question}\n\nДай точный технический ответ на основе предоставленного контекста."
+// Complete generates a chat completion
+func (p *OpenAIProvider) Complete(ctx context.Context, req ChatRequest) (*types.Answer, error) {
+	res, err := p.client.Chat.Completions.New(ctx, chatParams(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate completion: %w", err)
+	}
 
-	answerPaths := []string{
-		"prompts/answer_prompt.txt",
-		"./prompts/answer_prompt.txt",
-		"../prompts/answer_prompt.txt",
+	if len(res.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
 	}
-	for _, path := range answerPaths {
-		if p, err := loadPrompt(path); err == nil {
-			answerPromptTemplate = p
-			break
-		}
+
+	return &types.Answer{
+		Text:  res.Choices[0].Message.Content,
+		Model: res.Model,
+		Usage: types.TokenUsage{
+			PromptTokens:     res.Usage.PromptTokens,
+			CompletionTokens: res.Usage.CompletionTokens,
+			TotalTokens:      res.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// StreamComplete generates a chat completion, calling onToken for every content delta as it arrives
+func (p *OpenAIProvider) StreamComplete(ctx context.Context, req ChatRequest, onToken func(token string) error) (*types.Answer, error) {
+	params := chatParams(req)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.Bool(true),
 	}
 
-	// Replace placeholders
-	answerPrompt := strings.ReplaceAll(answerPromptTemplate, "{context}", contextText)
-	answerPrompt = strings.ReplaceAll(answerPrompt, "{question}", question)
+	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
 
-	// Create chat completion using OpenAI Go client
-	systemMsg := openai.SystemMessage(systemPrompt)
-	userMsg := openai.UserMessage(answerPrompt)
-	res, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Model: shared.ChatModel(c.model),
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			systemMsg,
-			userMsg,
-		},
-		Temperature: param.Opt[float64]{Value: 0.7},
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to generate completion: %w", err)
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		if err := onToken(chunk.Choices[0].Delta.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to stream completion: %w", err)
 	}
 
-	if len(res.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+	if len(acc.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
 	}
 
-	return res.Choices[0].Message.Content, nil
+	return &types.Answer{
+		Text:  acc.Choices[0].Message.Content,
+		Model: acc.Model,
+		Usage: types.TokenUsage{
+			PromptTokens:     acc.Usage.PromptTokens,
+			CompletionTokens: acc.Usage.CompletionTokens,
+			TotalTokens:      acc.Usage.TotalTokens,
+		},
+	}, nil
 }
 
-// GenerateEmbedding generates an embedding for the given text
-func (c *Client) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	input := openai.EmbeddingNewParamsInputUnion{
-		OfString: param.Opt[string]{Value: text},
+// Embed generates embeddings for a batch of texts
+func (p *OpenAIProvider) Embed(ctx context.Context, req EmbeddingRequest) ([][]float32, error) {
+	params := openai.EmbeddingNewParams{
+		Model: openai.EmbeddingModel(req.Model),
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: req.Input},
 	}
-	res, err := c.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
-		Model: openai.EmbeddingModel(c.embedModel),
-		Input: input,
-	})
+	if req.Dimensions > 0 {
+		params.Dimensions = openai.Int(int64(req.Dimensions))
+	}
+
+	res, err := p.client.Embeddings.New(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
-	if len(res.Data) == 0 {
-		return nil, fmt.Errorf("no embedding data in response")
+	if len(res.Data) != len(req.Input) {
+		return nil, fmt.Errorf("expected %d embeddings in response, got %d", len(req.Input), len(res.Data))
 	}
 
-	// Convert []float64 to []float32 for Qdrant
-	embedding := make([]float32, len(res.Data[0].Embedding))
-	for i, v := range res.Data[0].Embedding {
-		embedding[i] = float32(v)
+	// The API reports each embedding's position in the batch; do not rely on response order
+	embeddings := make([][]float32, len(req.Input))
+	for _, data := range res.Data {
+		if data.Index < 0 || int(data.Index) >= len(req.Input) || embeddings[data.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index %d in response", data.Index)
+		}
+		embeddings[data.Index] = toFloat32(data.Embedding)
 	}
 
-	return embedding, nil
+	return embeddings, nil
 }
 
-// loadPrompt loads a prompt from a file
-func loadPrompt(path string) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+// chatParams converts a ChatRequest into OpenAI chat completion parameters
+func chatParams(req ChatRequest) openai.ChatCompletionNewParams {
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case RoleSystem:
+			messages = append(messages, openai.SystemMessage(msg.Content))
+		case RoleAssistant:
+			messages = append(messages, openai.AssistantMessage(msg.Content))
+		default:
+			messages = append(messages, openai.UserMessage(msg.Content))
+		}
+	}
+
+	return openai.ChatCompletionNewParams{
+		Model:       shared.ChatModel(req.Model),
+		Messages:    messages,
+		Temperature: param.Opt[float64]{Value: req.Temperature},
+	}
+}
+
+// toFloat32 converts an OpenAI embedding to the []float32 representation used by Qdrant
+func toFloat32(values []float64) []float32 {
+	embedding := make([]float32, len(values))
+	for i, v := range values {
+		embedding[i] = float32(v)
 	}
-	return strings.TrimSpace(string(data)), nil
+	return embedding
 }
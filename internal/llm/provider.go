@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// Supported provider names
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible"
+	ProviderOllama           = "ollama"
+)
+
+// defaultOllamaBaseURL is the OpenAI-compatible endpoint of a local Ollama server
+const defaultOllamaBaseURL = "http://localhost:11434/v1"
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message represents a single chat message
+type Message struct {
+	Role    string
+	Content string
+}
+
+// ChatRequest represents a chat completion request
+type ChatRequest struct {
+	Model       string
+	Messages    []Message
+	Temperature float64
+}
+
+// EmbeddingRequest represents an embeddings request for one batch of texts
+type EmbeddingRequest struct {
+	Model string
+	Input []string
+	// Dimensions, if positive, asks the backend to shorten embeddings to that size
+	Dimensions int
+}
+
+//go:generate mockgen -source=provider.go -destination=mock_provider.go -package=llm -self_package=github.com/vokinneberg/ya-practicum-go-and-llm/internal/llm Provider
+
+// Provider defines the interface for an LLM backend supporting chat completions and embeddings
+type Provider interface {
+	Complete(ctx context.Context, req ChatRequest) (*types.Answer, error)
+	StreamComplete(ctx context.Context, req ChatRequest, onToken func(token string) error) (*types.Answer, error)
+	// Embed returns one embedding per input text, in input order
+	Embed(ctx context.Context, req EmbeddingRequest) ([][]float32, error)
+}
+
+// ProviderConfig holds settings for creating a provider
+type ProviderConfig struct {
+	Name    string
+	APIKey  string
+	BaseURL string
+}
+
+// NewProvider creates the provider selected by name
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	switch cfg.Name {
+	case ProviderOpenAI, "":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("API key is required for provider %q", ProviderOpenAI)
+		}
+		return NewOpenAIProvider(cfg.APIKey, cfg.BaseURL), nil
+	case ProviderOpenAICompatible:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("base URL is required for provider %q", ProviderOpenAICompatible)
+		}
+		return NewOpenAIProvider(cfg.APIKey, cfg.BaseURL), nil
+	case ProviderOllama:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = defaultOllamaBaseURL
+		}
+		return NewOpenAIProvider(cfg.APIKey, baseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Name)
+	}
+}
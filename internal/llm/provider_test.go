@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ProviderConfig
+		wantErr bool
+	}{
+		{
+			name: "openai with key",
+			cfg:  ProviderConfig{Name: ProviderOpenAI, APIKey: "sk-test"},
+		},
+		{
+			name:    "openai without key",
+			cfg:     ProviderConfig{Name: ProviderOpenAI},
+			wantErr: true,
+		},
+		{
+			name: "openai-compatible without key",
+			cfg:  ProviderConfig{Name: ProviderOpenAICompatible, BaseURL: "http://localhost:8081/v1"},
+		},
+		{
+			name:    "openai-compatible without base URL",
+			cfg:     ProviderConfig{Name: ProviderOpenAICompatible},
+			wantErr: true,
+		},
+		{
+			name: "ollama with defaults",
+			cfg:  ProviderConfig{Name: ProviderOllama},
+		},
+		{
+			name:    "unknown provider",
+			cfg:     ProviderConfig{Name: "anthropic"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(tt.cfg)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NewProvider() expected error but got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewProvider() unexpected error: %v", err)
+			}
+			if provider == nil {
+				t.Fatal("NewProvider() returned nil provider")
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_EmbedKeyless(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("request path = %q, want %q", r.URL.Path, "/v1/embeddings")
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization header = %q, want none", auth)
+		}
+
+		// Return embeddings out of order to verify they are placed by index
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"model":  "nomic-embed-text",
+			"data": []map[string]any{
+				{"object": "embedding", "index": 1, "embedding": []float64{2, 2}},
+				{"object": "embedding", "index": 0, "embedding": []float64{1, 1}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	// Make sure the SDK does not pick up a key from the environment
+	t.Setenv("OPENAI_API_KEY", "")
+	os.Unsetenv("OPENAI_API_KEY")
+	provider := NewOpenAIProvider("", server.URL+"/v1")
+
+	got, err := provider.Embed(context.Background(), EmbeddingRequest{
+		Model: "nomic-embed-text",
+		Input: []string{"first", "second"},
+	})
+	if err != nil {
+		t.Fatalf("Embed() unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0][0] != 1 || got[1][0] != 2 {
+		t.Errorf("Embed() = %v, want [[1 1] [2 2]]", got)
+	}
+}
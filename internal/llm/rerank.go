@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// relevanceTemperature keeps relevance scoring deterministic
+const relevanceTemperature = 0
+
+// ScoreRelevance asks the chat model to rate how well each passage answers the question.
+// It returns one score per passage, in passage order, on a 0-10 scale.
+func (c *Client) ScoreRelevance(ctx context.Context, question string, passages []string) ([]float64, error) {
+	if len(passages) == 0 {
+		return nil, nil
+	}
+
+	answer, err := c.provider.Complete(ctx, ChatRequest{
+		Model:       c.model,
+		Messages:    buildRerankMessages(question, passages),
+		Temperature: relevanceTemperature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to score relevance: %w", err)
+	}
+
+	scores, err := parseRelevanceScores(answer.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse relevance scores: %w", err)
+	}
+	if len(scores) != len(passages) {
+		return nil, fmt.Errorf("expected %d relevance scores, got %d", len(passages), len(scores))
+	}
+
+	return scores, nil
+}
+
+// buildRerankMessages builds the user message asking to score numbered passages against a question
+func buildRerankMessages(question string, passages []string) []Message {
+	promptTemplate := findPrompt("rerank_prompt.txt", "Оцени, насколько каждый фрагмент помогает ответить на вопрос, по шкале от 0 (не относится к вопросу) до 10 (содержит прямой ответ).\n\nВопрос: {question}\n\nФрагменты:\n{passages}\n\nВерни только JSON-массив чисел — по одной оценке на каждый фрагмент в том же порядке, например [7, 0, 3].")
+
+	var passagesBuilder strings.Builder
+	for i, passage := range passages {
+		fmt.Fprintf(&passagesBuilder, "[%d]\n%s\n\n", i+1, passage)
+	}
+
+	prompt := strings.ReplaceAll(promptTemplate, "{question}", question)
+	prompt = strings.ReplaceAll(prompt, "{passages}", strings.TrimSpace(passagesBuilder.String()))
+
+	return []Message{
+		{Role: RoleUser, Content: prompt},
+	}
+}
+
+// parseRelevanceScores extracts the JSON array of scores from the model output,
+// tolerating surrounding text or Markdown code fences
+func parseRelevanceScores(text string) ([]float64, error) {
+	start := strings.Index(text, "[")
+	end := strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in %q", text)
+	}
+
+	var scores []float64
+	if err := json.Unmarshal([]byte(text[start:end+1]), &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
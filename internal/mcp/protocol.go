@@ -0,0 +1,112 @@
+package mcp
+
+import "encoding/json"
+
+// jsonrpcVersion is the JSON-RPC version spoken by MCP
+const jsonrpcVersion = "2.0"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// latestProtocolVersion is the newest MCP revision implemented by the server
+const latestProtocolVersion = "2025-06-18"
+
+// supportedProtocolVersions are the MCP revisions the server can negotiate
+var supportedProtocolVersions = map[string]bool{
+	"2025-06-18": true,
+	"2025-03-26": true,
+	"2024-11-05": true,
+}
+
+// request is a JSON-RPC request, or a notification when ID is absent
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// isNotification reports whether the request expects no response
+func (r *request) isNotification() bool {
+	return len(r.ID) == 0
+}
+
+// response is a JSON-RPC response carrying either a result or an error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// initializeParams are the parameters of the initialize request
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+// initializeResult is the result of the initialize request
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// implementation names an MCP client or server
+type implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// tool describes a tool in the tools/list result
+type tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// listToolsResult is the result of the tools/list request
+type listToolsResult struct {
+	Tools []tool `json:"tools"`
+}
+
+// callToolParams are the parameters of the tools/call request
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// callToolResult is the result of the tools/call request. Tool failures are reported
+// with IsError so that the model can see them, rather than as JSON-RPC errors.
+type callToolResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// textContent is a text content block of a tool result
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// textResult returns a successful tool result with a single text block
+func textResult(text string) *callToolResult {
+	return &callToolResult{Content: []textContent{{Type: "text", Text: text}}}
+}
+
+// errorResult returns a failed tool result with the error message
+func errorResult(text string) *callToolResult {
+	return &callToolResult{Content: []textContent{{Type: "text", Text: text}}, IsError: true}
+}
@@ -0,0 +1,162 @@
+// Package mcp serves the RAG pipeline to coding assistants over the Model Context Protocol.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// serverName and serverVersion identify the server to MCP clients
+const (
+	serverName    = "ya-practicum-go-and-llm"
+	serverVersion = "1.0.0"
+)
+
+// serverInstructions tell the client model when to use the tools
+const serverInstructions = "Tools over the team knowledge base. Use search_docs to find relevant passages, ask for a generated answer with sources, ingest_document to add a document and list_documents to see what is indexed."
+
+//go:generate mockgen -source=server.go -destination=mock_pipeline.go -package=mcp Pipeline
+
+// Pipeline defines the RAG pipeline operations exposed as tools
+type Pipeline interface {
+	Retrieve(ctx context.Context, query string, opts rag.RetrieveOptions) ([]rag.SearchResult, error)
+	Ingest(ctx context.Context, text string, docID string, metadata map[string]any) error
+	ListDocuments(ctx context.Context, limit int, offset string) (*types.DocumentListResponse, error)
+}
+
+//go:generate mockgen -source=server.go -destination=mock_llmclient.go -package=mcp LLMClient
+
+// LLMClient defines the interface for LLM answer generation
+type LLMClient interface {
+	GenerateAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage) (*types.Answer, error)
+}
+
+// Server handles MCP JSON-RPC messages independently of the transport
+type Server struct {
+	pipeline  Pipeline
+	llmClient LLMClient
+	tools     map[string]toolHandler
+}
+
+// NewServer creates an MCP server exposing the pipeline as tools
+func NewServer(pipeline Pipeline, llmClient LLMClient) *Server {
+	s := &Server{
+		pipeline:  pipeline,
+		llmClient: llmClient,
+	}
+	s.tools = map[string]toolHandler{
+		"search_docs":     s.searchDocs,
+		"ask":             s.ask,
+		"ingest_document": s.ingestDocument,
+		"list_documents":  s.listDocuments,
+	}
+	return s
+}
+
+// HandleMessage processes a single JSON-RPC message and returns the encoded response,
+// or nil if the message is a notification or a response that needs no reply
+func (s *Server) HandleMessage(ctx context.Context, data []byte) []byte {
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return encodeResponse(response{
+			JSONRPC: jsonrpcVersion,
+			ID:      json.RawMessage("null"),
+			Error:   &rpcError{Code: codeParseError, Message: fmt.Sprintf("Parse error: %v", err)},
+		})
+	}
+
+	// Responses to server requests and notifications need no reply
+	if req.Method == "" && !req.isNotification() {
+		return nil
+	}
+	if req.isNotification() {
+		slog.Debug("Received MCP notification", "method", req.Method)
+		return nil
+	}
+
+	// A response holds either a result or an error, never both; a handler's typed nil
+	// result would otherwise be encoded as "result": null next to the error
+	result, rpcErr := s.dispatch(ctx, &req)
+	resp := response{JSONRPC: jsonrpcVersion, ID: req.ID}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return encodeResponse(resp)
+}
+
+// dispatch runs the method of a request
+func (s *Server) dispatch(ctx context.Context, req *request) (any, *rpcError) {
+	if req.JSONRPC != jsonrpcVersion {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "Invalid request: jsonrpc must be \"2.0\""}
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		version := params.ProtocolVersion
+		if !supportedProtocolVersions[version] {
+			version = latestProtocolVersion
+		}
+		return initializeResult{
+			ProtocolVersion: version,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      implementation{Name: serverName, Version: serverVersion},
+			Instructions:    serverInstructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return listToolsResult{Tools: toolDefinitions}, nil
+	case "tools/call":
+		var params callToolParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		handler, ok := s.tools[params.Name]
+		if !ok {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("Unknown tool %q", params.Name)}
+		}
+		arguments := params.Arguments
+		if len(arguments) == 0 {
+			arguments = json.RawMessage("{}")
+		}
+		return handler(ctx, arguments)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("Method not found: %s", req.Method)}
+	}
+}
+
+// unmarshalParams decodes request parameters, reporting failures as invalid params
+func unmarshalParams(params json.RawMessage, v any) *rpcError {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("Invalid params: %v", err)}
+	}
+	return nil
+}
+
+// encodeResponse encodes a JSON-RPC response
+func encodeResponse(resp response) []byte {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		slog.Error("Error encoding MCP response", "error", err)
+		data, _ = json.Marshal(response{
+			JSONRPC: jsonrpcVersion,
+			ID:      resp.ID,
+			Error:   &rpcError{Code: codeInternalError, Message: "Failed to encode response"},
+		})
+	}
+	return data
+}
@@ -0,0 +1,297 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// decodedResponse is a JSON-RPC response with a tool call result
+type decodedResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func TestServer_HandleMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		message      string
+		wantNoReply  bool
+		wantErrCode  int
+		wantContains []string
+	}{
+		{
+			name:         "initialize negotiates a supported version",
+			message:      `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-03-26", "capabilities": {}, "clientInfo": {"name": "test", "version": "1"}}}`,
+			wantContains: []string{`"protocolVersion":"2025-03-26"`, `"tools":{}`, `"name":"ya-practicum-go-and-llm"`},
+		},
+		{
+			name:         "initialize falls back to the latest version",
+			message:      `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "1999-01-01"}}`,
+			wantContains: []string{`"protocolVersion":"` + latestProtocolVersion + `"`},
+		},
+		{
+			name:        "notification gets no reply",
+			message:     `{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+			wantNoReply: true,
+		},
+		{
+			name:         "ping",
+			message:      `{"jsonrpc": "2.0", "id": "abc", "method": "ping"}`,
+			wantContains: []string{`"id":"abc"`, `"result":{}`},
+		},
+		{
+			name:         "tools list",
+			message:      `{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`,
+			wantContains: []string{`"name":"search_docs"`, `"name":"ask"`, `"name":"ingest_document"`, `"name":"list_documents"`, `"inputSchema"`},
+		},
+		{
+			name:        "unknown method",
+			message:     `{"jsonrpc": "2.0", "id": 3, "method": "resources/list"}`,
+			wantErrCode: codeMethodNotFound,
+		},
+		{
+			name:        "unknown tool",
+			message:     `{"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": {"name": "drop_database"}}`,
+			wantErrCode: codeInvalidParams,
+		},
+		{
+			name:        "missing required argument",
+			message:     `{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": {"name": "search_docs", "arguments": {}}}`,
+			wantErrCode: codeInvalidParams,
+		},
+		{
+			name:        "invalid JSON",
+			message:     `{"jsonrpc": "2.0", "id": `,
+			wantErrCode: codeParseError,
+		},
+		{
+			name:        "wrong JSON-RPC version",
+			message:     `{"jsonrpc": "1.0", "id": 6, "method": "ping"}`,
+			wantErrCode: codeInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(nil, nil)
+
+			reply := server.HandleMessage(context.Background(), []byte(tt.message))
+			if tt.wantNoReply {
+				if reply != nil {
+					t.Errorf("HandleMessage() = %s, want no reply", reply)
+				}
+				return
+			}
+
+			var resp decodedResponse
+			if err := json.Unmarshal(reply, &resp); err != nil {
+				t.Fatalf("HandleMessage() reply %s is not JSON: %v", reply, err)
+			}
+			if tt.wantErrCode != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.wantErrCode {
+					t.Errorf("HandleMessage() error = %+v, want code %d", resp.Error, tt.wantErrCode)
+				}
+				// JSON-RPC 2.0 forbids a result next to an error
+				var fields map[string]json.RawMessage
+				if err := json.Unmarshal(reply, &fields); err != nil {
+					t.Fatalf("HandleMessage() reply %s is not a JSON object: %v", reply, err)
+				}
+				if _, ok := fields["result"]; ok {
+					t.Errorf("HandleMessage() = %s, want no result in an error response", reply)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("HandleMessage() unexpected error: %+v", resp.Error)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(string(reply), want) {
+					t.Errorf("HandleMessage() = %s, want containing %q", reply, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Tools(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        string
+		arguments   string
+		setupMocks  func(pipeline *MockPipeline, llm *MockLLMClient)
+		wantIsError bool
+		wantText    []string
+	}{
+		{
+			name:      "search_docs returns passages with sources",
+			tool:      "search_docs",
+			arguments: `{"query": "max connections", "filter": {"tags": ["ops"]}}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "max connections", rag.RetrieveOptions{Filter: &types.QueryFilter{Tags: []string{"ops"}}}).
+					Return([]rag.SearchResult{{Text: "--max-connections defaults to 100", Score: 0.9, DocID: "flags", ChunkIndex: 2}}, nil)
+			},
+			wantText: []string{"[1] doc_id=flags chunk=2 score=0.9000\n--max-connections defaults to 100"},
+		},
+		{
+			name:      "search_docs with a source filter",
+			tool:      "search_docs",
+			arguments: `{"query": "max connections", "filter": {"source": "wiki", "doc_ids": ["flags"]}}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "max connections", rag.RetrieveOptions{Filter: &types.QueryFilter{Source: "wiki", DocIDs: []string{"flags"}}}).
+					Return([]rag.SearchResult{{Text: "--max-connections defaults to 100", Score: 0.9, DocID: "flags"}}, nil)
+			},
+			wantText: []string{"doc_id=flags"},
+		},
+		{
+			name:      "search_docs without relevant documents",
+			tool:      "search_docs",
+			arguments: `{"query": "weather on Mars"}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().Retrieve(gomock.Any(), "weather on Mars", gomock.Any()).Return(nil, rag.ErrNoRelevantDocuments)
+			},
+			wantText: []string{noRelevantDocumentsText},
+		},
+		{
+			name:      "search_docs failure is a tool error",
+			tool:      "search_docs",
+			arguments: `{"query": "max connections"}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().Retrieve(gomock.Any(), "max connections", gomock.Any()).Return(nil, errors.New("qdrant unavailable"))
+			},
+			wantIsError: true,
+			wantText:    []string{"qdrant unavailable"},
+		},
+		{
+			name:      "ask answers with sources",
+			tool:      "ask",
+			arguments: `{"question": "What is the default of --max-connections?"}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					Retrieve(gomock.Any(), "What is the default of --max-connections?", gomock.Any()).
+					Return([]rag.SearchResult{{Text: "--max-connections defaults to 100", Score: 0.9, DocID: "flags", ChunkIndex: 2}}, nil)
+				llm.EXPECT().
+					GenerateAnswer(gomock.Any(), gomock.Any(), "What is the default of --max-connections?", nil).
+					Return(&types.Answer{Text: "The default is 100."}, nil)
+			},
+			wantText: []string{"The default is 100.\n\nSources:\n[1] doc_id=flags chunk=2 score=0.9000"},
+		},
+		{
+			name:      "ask generation failure is a tool error",
+			tool:      "ask",
+			arguments: `{"question": "test"}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().Retrieve(gomock.Any(), "test", gomock.Any()).Return([]rag.SearchResult{{Text: "context"}}, nil)
+				llm.EXPECT().GenerateAnswer(gomock.Any(), gomock.Any(), "test", nil).Return(nil, errors.New("LLM error"))
+			},
+			wantIsError: true,
+			wantText:    []string{"LLM error"},
+		},
+		{
+			name:      "ingest_document with metadata",
+			tool:      "ingest_document",
+			arguments: `{"text": "Runbook text", "id": "runbook", "metadata": {"source": "wiki"}}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().Ingest(gomock.Any(), "Runbook text", "runbook", map[string]any{"source": "wiki"}).Return(nil)
+			},
+			wantText: []string{`Document "runbook" ingested.`},
+		},
+		{
+			name:      "ingest_document without id uses the text hash",
+			tool:      "ingest_document",
+			arguments: `{"text": "Runbook text"}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().Ingest(gomock.Any(), "Runbook text", documentID("Runbook text"), gomock.Any()).Return(nil)
+			},
+			wantText: []string{documentID("Runbook text")},
+		},
+		{
+			name:      "ingest_document with invalid metadata",
+			tool:      "ingest_document",
+			arguments: `{"text": "Runbook text", "metadata": {"doc_id": "x"}}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().Ingest(gomock.Any(), "Runbook text", gomock.Any(), gomock.Any()).Return(rag.ErrInvalidMetadata)
+			},
+			wantIsError: true,
+			wantText:    []string{"invalid metadata"},
+		},
+		{
+			name:      "list_documents caps the limit",
+			tool:      "list_documents",
+			arguments: `{"limit": 1000, "offset": "abc"}`,
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					ListDocuments(gomock.Any(), maxDocumentsLimit, "abc").
+					Return(&types.DocumentListResponse{Documents: []types.Document{{ID: "runbook", ChunkCount: 3}}}, nil)
+			},
+			wantText: []string{`"id": "runbook"`, `"chunk_count": 3`},
+		},
+		{
+			name: "list_documents without arguments",
+			tool: "list_documents",
+			setupMocks: func(pipeline *MockPipeline, llm *MockLLMClient) {
+				pipeline.EXPECT().
+					ListDocuments(gomock.Any(), defaultDocumentsLimit, "").
+					Return(&types.DocumentListResponse{Documents: []types.Document{}}, nil)
+			},
+			wantText: []string{`"documents": []`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPipeline := NewMockPipeline(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockPipeline, mockLLM)
+			}
+
+			params := map[string]any{"name": tt.tool}
+			if tt.arguments != "" {
+				params["arguments"] = json.RawMessage(tt.arguments)
+			}
+			message, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": params})
+			if err != nil {
+				t.Fatalf("Failed to marshal message: %v", err)
+			}
+
+			reply := NewServer(mockPipeline, mockLLM).HandleMessage(context.Background(), message)
+
+			var resp decodedResponse
+			if err := json.Unmarshal(reply, &resp); err != nil {
+				t.Fatalf("HandleMessage() reply %s is not JSON: %v", reply, err)
+			}
+			if resp.Error != nil {
+				t.Fatalf("HandleMessage() unexpected error: %+v", resp.Error)
+			}
+
+			var result callToolResult
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				t.Fatalf("HandleMessage() result %s is not a tool result: %v", resp.Result, err)
+			}
+			if result.IsError != tt.wantIsError {
+				t.Errorf("tools/call isError = %v, want %v", result.IsError, tt.wantIsError)
+			}
+			if len(result.Content) != 1 || result.Content[0].Type != "text" {
+				t.Fatalf("tools/call content = %+v, want a single text block", result.Content)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(result.Content[0].Text, want) {
+					t.Errorf("tools/call text = %q, want containing %q", result.Content[0].Text, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// List limits of the list_documents tool
+const (
+	defaultDocumentsLimit = 20
+	maxDocumentsLimit     = 100
+)
+
+// noRelevantDocumentsText is the tool output when retrieval finds nothing relevant enough
+const noRelevantDocumentsText = "No relevant documents found in the knowledge base."
+
+// toolHandler runs a tool with its JSON arguments
+type toolHandler func(ctx context.Context, arguments json.RawMessage) (*callToolResult, *rpcError)
+
+// filterSchema is the input schema of the optional retrieval filter
+var filterSchema = map[string]any{
+	"type":        "object",
+	"description": "Restrict retrieval to chunks matching all given conditions",
+	"properties": map[string]any{
+		"doc_ids": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Document IDs, any of"},
+		"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Metadata tags, any of"},
+		"source":  map[string]any{"type": "string", "description": "Metadata source, exact match"},
+	},
+}
+
+// toolDefinitions are the tools listed to clients
+var toolDefinitions = []tool{
+	{
+		Name:        "search_docs",
+		Description: "Search the knowledge base and return the most relevant passages with their document IDs and scores.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query":  map[string]any{"type": "string", "description": "Search query"},
+				"filter": filterSchema,
+			},
+			"required": []string{"query"},
+		},
+	},
+	{
+		Name:        "ask",
+		Description: "Answer a question from the knowledge base. Returns a generated answer followed by the sources it is based on.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"question": map[string]any{"type": "string", "description": "Question to answer"},
+				"filter":   filterSchema,
+			},
+			"required": []string{"question"},
+		},
+	},
+	{
+		Name:        "ingest_document",
+		Description: "Add a document to the knowledge base. Ingesting an existing ID replaces the document.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"text":     map[string]any{"type": "string", "description": "Document text"},
+				"id":       map[string]any{"type": "string", "description": "Document ID; the SHA-256 of the text if omitted"},
+				"metadata": map[string]any{"type": "object", "description": "Metadata stored with every chunk, e.g. source and tags"},
+			},
+			"required": []string{"text"},
+		},
+	},
+	{
+		Name:        "list_documents",
+		Description: "List documents in the knowledge base with their chunk counts.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"limit":  map[string]any{"type": "integer", "description": "Maximum number of documents, 1 to 100", "minimum": 1, "maximum": maxDocumentsLimit},
+				"offset": map[string]any{"type": "string", "description": "next_offset of the previous page"},
+			},
+		},
+	},
+}
+
+// searchArgs are the arguments of the search_docs tool
+type searchArgs struct {
+	Query  string             `json:"query"`
+	Filter *types.QueryFilter `json:"filter,omitempty"`
+}
+
+// searchDocs returns the retrieved passages
+func (s *Server) searchDocs(ctx context.Context, arguments json.RawMessage) (*callToolResult, *rpcError) {
+	var args searchArgs
+	if err := unmarshalParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Query == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "query is required"}
+	}
+
+	results, err := s.pipeline.Retrieve(ctx, args.Query, rag.RetrieveOptions{Filter: args.Filter})
+	if errors.Is(err, rag.ErrNoRelevantDocuments) {
+		return textResult(noRelevantDocumentsText), nil
+	}
+	if err != nil {
+		slog.Error("Error retrieving context", "error", err, "query", args.Query)
+		return errorResult(fmt.Sprintf("Failed to search documents: %v", err)), nil
+	}
+
+	var b strings.Builder
+	for i, result := range results {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%d] %s\n%s", i+1, formatSource(result), result.Text)
+	}
+	return textResult(b.String()), nil
+}
+
+// askArgs are the arguments of the ask tool
+type askArgs struct {
+	Question string             `json:"question"`
+	Filter   *types.QueryFilter `json:"filter,omitempty"`
+}
+
+// ask answers a question with retrieved context and lists the sources
+func (s *Server) ask(ctx context.Context, arguments json.RawMessage) (*callToolResult, *rpcError) {
+	var args askArgs
+	if err := unmarshalParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Question == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "question is required"}
+	}
+
+	results, err := s.pipeline.Retrieve(ctx, args.Question, rag.RetrieveOptions{Filter: args.Filter})
+	if errors.Is(err, rag.ErrNoRelevantDocuments) {
+		// Nothing to ground an answer on, skip generation
+		return textResult(noRelevantDocumentsText), nil
+	}
+	if err != nil {
+		slog.Error("Error retrieving context", "error", err, "query", args.Question)
+		return errorResult(fmt.Sprintf("Failed to retrieve context: %v", err)), nil
+	}
+
+	answer, err := s.llmClient.GenerateAnswer(ctx, rag.BuildContext(results), args.Question, nil)
+	if err != nil {
+		slog.Error("Error generating answer", "error", err, "query", args.Question)
+		return errorResult(fmt.Sprintf("Failed to generate answer: %v", err)), nil
+	}
+
+	var b strings.Builder
+	b.WriteString(answer.Text)
+	b.WriteString("\n\nSources:")
+	for i, result := range results {
+		fmt.Fprintf(&b, "\n[%d] %s", i+1, formatSource(result))
+	}
+	return textResult(b.String()), nil
+}
+
+// ingestArgs are the arguments of the ingest_document tool
+type ingestArgs struct {
+	Text     string         `json:"text"`
+	ID       string         `json:"id,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// ingestDocument adds a document to the knowledge base
+func (s *Server) ingestDocument(ctx context.Context, arguments json.RawMessage) (*callToolResult, *rpcError) {
+	var args ingestArgs
+	if err := unmarshalParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Text == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "text is required"}
+	}
+	// Documents need an ID to be listed, replaced and deleted later
+	if args.ID == "" {
+		args.ID = documentID(args.Text)
+	}
+
+	if err := s.pipeline.Ingest(ctx, args.Text, args.ID, args.Metadata); err != nil {
+		if !errors.Is(err, rag.ErrInvalidMetadata) {
+			slog.Error("Error ingesting document", "error", err, "doc_id", args.ID)
+		}
+		return errorResult(fmt.Sprintf("Failed to ingest document: %v", err)), nil
+	}
+
+	return textResult(fmt.Sprintf("Document %q ingested.", args.ID)), nil
+}
+
+// documentID derives a document ID from the document text
+func documentID(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// listArgs are the arguments of the list_documents tool
+type listArgs struct {
+	Limit  int    `json:"limit,omitempty"`
+	Offset string `json:"offset,omitempty"`
+}
+
+// listDocuments returns a page of documents as JSON
+func (s *Server) listDocuments(ctx context.Context, arguments json.RawMessage) (*callToolResult, *rpcError) {
+	var args listArgs
+	if err := unmarshalParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Limit < 0 {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "limit must be positive"}
+	}
+	limit := defaultDocumentsLimit
+	if args.Limit > 0 {
+		limit = min(args.Limit, maxDocumentsLimit)
+	}
+
+	documents, err := s.pipeline.ListDocuments(ctx, limit, args.Offset)
+	if err != nil {
+		slog.Error("Error listing documents", "error", err)
+		return errorResult(fmt.Sprintf("Failed to list documents: %v", err)), nil
+	}
+
+	data, err := json.MarshalIndent(documents, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Failed to encode documents: %v", err)), nil
+	}
+	return textResult(string(data)), nil
+}
+
+// formatSource describes where a retrieved chunk comes from
+func formatSource(result rag.SearchResult) string {
+	source := fmt.Sprintf("score=%.4f", result.Score)
+	if result.DocID != "" {
+		source = fmt.Sprintf("doc_id=%s chunk=%d %s", result.DocID, result.ChunkIndex, source)
+	}
+	if result.HeadingPath != "" {
+		source += fmt.Sprintf(" section=%q", result.HeadingPath)
+	}
+	if result.FilePath != "" {
+		source += fmt.Sprintf(" file=%s:%d-%d", result.FilePath, result.StartLine, result.EndLine)
+	}
+	if result.Symbol != "" {
+		source += fmt.Sprintf(" symbol=%s", result.Symbol)
+	}
+	if result.Offsets != nil {
+		source += fmt.Sprintf(" offsets=%d-%d", result.Offsets.Start, result.Offsets.End)
+	}
+	return source
+}
@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer_ServeStdio(t *testing.T) {
+	input := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18"}}`,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		``,
+		`{"jsonrpc": "2.0", "id": 2, "method": "ping"}`,
+	}, "\n")
+
+	var output bytes.Buffer
+	if err := NewServer(nil, nil).ServeStdio(context.Background(), strings.NewReader(input), &output); err != nil {
+		t.Fatalf("ServeStdio() unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("ServeStdio() wrote %d lines, want 2 responses: %s", len(lines), output.String())
+	}
+	if !strings.Contains(lines[0], `"id":1`) || !strings.Contains(lines[0], `"protocolVersion":"2025-06-18"`) {
+		t.Errorf("ServeStdio() first response = %s, want the initialize result", lines[0])
+	}
+	if !strings.Contains(lines[1], `"id":2`) {
+		t.Errorf("ServeStdio() second response = %s, want the ping result", lines[1])
+	}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		origin       string
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:         "request gets a JSON response",
+			method:       http.MethodPost,
+			body:         `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`,
+			wantStatus:   http.StatusOK,
+			wantContains: `"name":"search_docs"`,
+		},
+		{
+			name:       "notification is accepted",
+			method:     http.MethodPost,
+			body:       `{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:         "local origin is allowed",
+			method:       http.MethodPost,
+			body:         `{"jsonrpc": "2.0", "id": 1, "method": "ping"}`,
+			origin:       "http://localhost:6274",
+			wantStatus:   http.StatusOK,
+			wantContains: `"result":{}`,
+		},
+		{
+			name:       "foreign origin is rejected",
+			method:     http.MethodPost,
+			body:       `{"jsonrpc": "2.0", "id": 1, "method": "ping"}`,
+			origin:     "https://evil.example.com",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "server-initiated stream is not offered",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/mcp", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json, text/event-stream")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+
+			NewServer(nil, nil).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantContains != "" {
+				if got := w.Header().Get("Content-Type"); got != "application/json" {
+					t.Errorf("ServeHTTP() Content-Type = %q, want application/json", got)
+				}
+				if !strings.Contains(w.Body.String(), tt.wantContains) {
+					t.Errorf("ServeHTTP() body = %s, want containing %q", w.Body.String(), tt.wantContains)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package rag
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// BM25 ranking parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// BM25Index is an in-process keyword index over chunk texts scored with Okapi BM25.
+// It complements dense search with exact matches on identifiers, flags and error codes.
+type BM25Index struct {
+	mu          sync.RWMutex
+	docs        map[string]*bm25Doc
+	docFreq     map[string]int
+	totalLength int
+}
+
+// bm25Doc is an indexed chunk with its term frequencies and payload for filtering
+type bm25Doc struct {
+	result    SearchResult
+	payload   map[string]*qdrant.Value
+	termFreqs map[string]int
+	length    int
+}
+
+// NewBM25Index creates an empty BM25 index
+func NewBM25Index() *BM25Index {
+	return &BM25Index{
+		docs:    make(map[string]*bm25Doc),
+		docFreq: make(map[string]int),
+	}
+}
+
+// Upsert indexes a chunk under the given point ID, replacing any previous version.
+// The payload is kept to apply search filters.
+func (idx *BM25Index) Upsert(id string, result SearchResult, payload map[string]*qdrant.Value) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+
+	terms := tokenize(result.Text)
+	termFreqs := make(map[string]int, len(terms))
+	for _, term := range terms {
+		termFreqs[term]++
+	}
+	for term := range termFreqs {
+		idx.docFreq[term]++
+	}
+
+	result.Score = 0
+	result.pointID = id
+	idx.docs[id] = &bm25Doc{
+		result:    result,
+		payload:   payload,
+		termFreqs: termFreqs,
+		length:    len(terms),
+	}
+	idx.totalLength += len(terms)
+}
+
+// DeleteDocument removes the chunks of a document with a chunk index of at least fromChunk
+func (idx *BM25Index) DeleteDocument(docID string, fromChunk int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for id, doc := range idx.docs {
+		if doc.result.DocID == docID && doc.result.ChunkIndex >= fromChunk {
+			idx.remove(id)
+		}
+	}
+}
+
+// Len returns the number of indexed chunks
+func (idx *BM25Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.docs)
+}
+
+// Search returns up to limit chunks matching the filter, ranked by BM25 score for the query
+func (idx *BM25Index) Search(query string, limit int, filter *qdrant.Filter) ([]SearchResult, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.docs) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	queryTerms := make(map[string]struct{})
+	for _, term := range tokenize(query) {
+		queryTerms[term] = struct{}{}
+	}
+
+	n := float64(len(idx.docs))
+	avgLength := float64(idx.totalLength) / n
+
+	var results []SearchResult
+	for _, doc := range idx.docs {
+		var score float64
+		for term := range queryTerms {
+			tf := float64(doc.termFreqs[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.length)/avgLength))
+		}
+		if score == 0 {
+			continue
+		}
+		if filter != nil {
+			ok, err := matchFilter(filter, doc.payload)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		result := doc.result
+		result.Score = float32(score)
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return resultKey(results[i]) < resultKey(results[j])
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// replace swaps the contents of the index for those of other
+func (idx *BM25Index) replace(other *BM25Index) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs, idx.docFreq, idx.totalLength = other.docs, other.docFreq, other.totalLength
+}
+
+// remove drops a chunk from the index; the caller must hold the write lock
+func (idx *BM25Index) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.termFreqs {
+		idx.docFreq[term]--
+		if idx.docFreq[term] == 0 {
+			delete(idx.docFreq, term)
+		}
+	}
+	idx.totalLength -= doc.length
+	delete(idx.docs, id)
+}
+
+// tokenize lowercases text and splits it into terms. Compound identifiers such as
+// "kube-apiserver" or "max_tokens" are kept whole and also indexed by their parts.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.'
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.Trim(word, "-_.")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, word)
+
+		parts := strings.FieldsFunc(word, func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+		if len(parts) > 1 {
+			terms = append(terms, parts...)
+		}
+	}
+
+	return terms
+}
@@ -0,0 +1,93 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "lowercases and splits on punctuation",
+			text: "Hello, World!",
+			want: []string{"hello", "world"},
+		},
+		{
+			name: "keeps compound identifiers and their parts",
+			text: "set --enable-admission-plugins",
+			want: []string{"set", "enable-admission-plugins", "enable", "admission", "plugins"},
+		},
+		{
+			name: "cyrillic text",
+			text: "Под — это группа контейнеров",
+			want: []string{"под", "это", "группа", "контейнеров"},
+		},
+		{
+			name: "error codes",
+			text: "ERR_CONNECTION_REFUSED (code 503)",
+			want: []string{"err_connection_refused", "err", "connection", "refused", "code", "503"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBM25Index_Search(t *testing.T) {
+	idx := NewBM25Index()
+	idx.Upsert("1", SearchResult{Text: "The kube-apiserver validates and configures data for the api objects", DocID: "api", ChunkIndex: 0}, nil)
+	idx.Upsert("2", SearchResult{Text: "The scheduler assigns pods to nodes", DocID: "sched", ChunkIndex: 0}, nil)
+	idx.Upsert("3", SearchResult{Text: "Pods are the smallest deployable units; pods run containers", DocID: "pods", ChunkIndex: 0}, nil)
+
+	results, _ := idx.Search("kube-apiserver flags", 10, nil)
+	if len(results) != 1 || results[0].DocID != "api" {
+		t.Fatalf("Search(kube-apiserver) = %+v, want only the api chunk", results)
+	}
+	if results[0].Score <= 0 {
+		t.Errorf("Search() score = %f, want positive", results[0].Score)
+	}
+
+	results, _ = idx.Search("pods", 10, nil)
+	if len(results) != 2 || results[0].DocID != "pods" {
+		t.Errorf("Search(pods) = %+v, want the chunk with more occurrences first", results)
+	}
+
+	if results, _ := idx.Search("etcd", 10, nil); len(results) != 0 {
+		t.Errorf("Search(etcd) = %+v, want no results", results)
+	}
+}
+
+func TestBM25Index_UpsertAndDelete(t *testing.T) {
+	idx := NewBM25Index()
+	idx.Upsert("a0", SearchResult{Text: "alpha", DocID: "doc", ChunkIndex: 0}, nil)
+	idx.Upsert("a1", SearchResult{Text: "beta", DocID: "doc", ChunkIndex: 1}, nil)
+	idx.Upsert("a2", SearchResult{Text: "gamma", DocID: "doc", ChunkIndex: 2}, nil)
+
+	// Re-indexing a chunk replaces its terms
+	idx.Upsert("a0", SearchResult{Text: "delta", DocID: "doc", ChunkIndex: 0}, nil)
+	if results, _ := idx.Search("alpha", 10, nil); len(results) != 0 {
+		t.Errorf("Search(alpha) after upsert = %+v, want no results", results)
+	}
+
+	idx.DeleteDocument("doc", 1)
+	if idx.Len() != 1 {
+		t.Errorf("Len() after deleting tail = %d, want 1", idx.Len())
+	}
+	if results, _ := idx.Search("delta", 10, nil); len(results) != 1 {
+		t.Errorf("Search(delta) = %+v, want the remaining chunk", results)
+	}
+
+	idx.DeleteDocument("doc", 0)
+	if idx.Len() != 0 {
+		t.Errorf("Len() after deleting document = %d, want 0", idx.Len())
+	}
+}
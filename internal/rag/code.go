@@ -0,0 +1,476 @@
+package rag
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Block structures of the supported programming languages
+const (
+	codeBlocksGo     = "go"
+	codeBlocksBraces = "braces"
+	codeBlocksIndent = "indent"
+)
+
+// codeExtensions maps file extensions to the block structure of their language
+var codeExtensions = map[string]string{
+	".go": codeBlocksGo,
+	// Languages with curly-brace blocks
+	".c": codeBlocksBraces, ".h": codeBlocksBraces, ".cc": codeBlocksBraces, ".cpp": codeBlocksBraces,
+	".cxx": codeBlocksBraces, ".hpp": codeBlocksBraces, ".cs": codeBlocksBraces, ".java": codeBlocksBraces,
+	".kt": codeBlocksBraces, ".kts": codeBlocksBraces, ".scala": codeBlocksBraces, ".groovy": codeBlocksBraces,
+	".swift": codeBlocksBraces, ".rs": codeBlocksBraces, ".js": codeBlocksBraces, ".jsx": codeBlocksBraces,
+	".mjs": codeBlocksBraces, ".cjs": codeBlocksBraces, ".ts": codeBlocksBraces, ".tsx": codeBlocksBraces,
+	".php": codeBlocksBraces, ".dart": codeBlocksBraces, ".proto": codeBlocksBraces,
+	// Languages with indented blocks
+	".py": codeBlocksIndent, ".pyi": codeBlocksIndent, ".rb": codeBlocksIndent,
+}
+
+// CodeChunker splits source code into chunks along declarations and records the file path,
+// symbol and line range of every chunk. Go files are parsed with go/parser and split into
+// top-level declarations; other languages are split into top-level blocks found by brace
+// nesting or indentation, descending into blocks such as classes that exceed the chunk size.
+// Markdown files are chunked by the Markdown chunker and other files by the recursive chunker.
+type CodeChunker struct {
+	chunkSize    int
+	chunkOverlap int
+}
+
+// NewCodeChunker creates a code chunker producing chunks of at most chunkSize characters, unless a
+// single line is longer. chunkOverlap only applies to files that are not source code.
+func NewCodeChunker(chunkSize, chunkOverlap int) *CodeChunker {
+	return &CodeChunker{
+		chunkSize:    chunkSize,
+		chunkOverlap: chunkOverlap,
+	}
+}
+
+// codeSpan is a span of source code holding the named symbol
+type codeSpan struct {
+	textSpan
+	symbol string
+}
+
+// ChunkText splits text into chunks, detecting Go code by its package clause
+func (c *CodeChunker) ChunkText(text string) []string {
+	chunks := c.ChunkFile("", text)
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+	return texts
+}
+
+// ChunkFile splits the file at path into chunks; the extension selects the language
+func (c *CodeChunker) ChunkFile(path, text string) []Chunk {
+	ext := strings.ToLower(filepath.Ext(path))
+	blocks, ok := codeExtensions[ext]
+	if path == "" && strings.HasPrefix(strings.TrimSpace(text), "package ") {
+		blocks, ok = codeBlocksGo, true
+	}
+	if !ok {
+		if ext == ".md" || ext == ".markdown" {
+			return NewMarkdownChunker(c.chunkSize).Chunk(text)
+		}
+		return NewRecursiveChunker(c.chunkSize, c.chunkOverlap).Chunk(text)
+	}
+
+	var spans []codeSpan
+	if blocks == codeBlocksGo {
+		spans, ok = c.goSpans(text)
+		if !ok {
+			// Fall back to braces for files go/parser rejects, e.g. templates
+			blocks = codeBlocksBraces
+		}
+	}
+	if blocks != codeBlocksGo {
+		lines := newCodeLines(text, blocks)
+		spans = c.blockSpans(text, lines, 0, len(lines.starts), 0, "")
+	}
+
+	var chunks []Chunk
+	positions := newTextPositions(text)
+	for _, span := range spans {
+		chunk, ok := positions.chunkLines(span.textSpan)
+		if !ok {
+			continue
+		}
+		chunk.FilePath = path
+		chunk.Symbol = span.symbol
+		chunk.StartLine = positions.line
+		chunk.EndLine = positions.line + strings.Count(chunk.Text, "\n")
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// goSpans splits Go source into the package clause with the imports and one span per top-level
+// declaration. Doc comments and comments between declarations belong to the next declaration.
+// It reports false if the source cannot be parsed.
+func (c *CodeChunker) goSpans(text string) ([]codeSpan, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", text, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+
+	var spans []codeSpan
+	start := 0
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		if len(spans) == 0 {
+			// The package clause and imports, up to the first declaration and its doc comment
+			headerEnd := offset(decl.Pos())
+			if doc := declDoc(decl); doc != nil {
+				headerEnd = offset(doc.Pos())
+			}
+			spans = append(spans, codeSpan{textSpan: textSpan{start: 0, end: headerEnd}, symbol: "package " + file.Name.Name})
+			start = headerEnd
+		}
+
+		span := textSpan{start: start, end: offset(decl.End())}
+		spans = append(spans, c.splitOversized(text, span, goDeclSymbol(decl))...)
+		start = span.end
+	}
+
+	if len(spans) == 0 {
+		return c.splitOversized(text, textSpan{start: 0, end: len(text)}, "package "+file.Name.Name), true
+	}
+	// Trailing comments belong to the last declaration
+	spans[len(spans)-1].end = len(text)
+	return spans, true
+}
+
+// declDoc returns the doc comment of a declaration
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		return decl.Doc
+	case *ast.GenDecl:
+		return decl.Doc
+	}
+	return nil
+}
+
+// goDeclSymbol names a declaration: "Func", "Type.Method" or the comma-separated names of a type,
+// const or var declaration
+func goDeclSymbol(decl ast.Decl) string {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv == nil || len(decl.Recv.List) == 0 {
+			return decl.Name.Name
+		}
+		return receiverTypeName(decl.Recv.List[0].Type) + "." + decl.Name.Name
+	case *ast.GenDecl:
+		var names []string
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					names = append(names, name.Name)
+				}
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// receiverTypeName returns the type name of a method receiver, without pointer and type parameters
+func receiverTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// splitOversized splits a span longer than the chunk size at line breaks, keeping its symbol
+func (c *CodeChunker) splitOversized(text string, span textSpan, symbol string) []codeSpan {
+	if c.chunkSize <= 0 || spanLength(text, span) <= c.chunkSize {
+		return []codeSpan{{textSpan: span, symbol: symbol}}
+	}
+
+	var spans []codeSpan
+	for _, piece := range NewRecursiveChunker(c.chunkSize, 0).split(text, span, lineSplitLevel) {
+		spans = append(spans, codeSpan{textSpan: piece, symbol: symbol})
+	}
+	return spans
+}
+
+// codeLines holds the lines of a source file with their nesting levels
+type codeLines struct {
+	text string
+	// starts holds the byte offset of every line
+	starts []int
+	// levels holds the nesting level of every line, or -1 for blank lines
+	levels []int
+}
+
+// newCodeLines splits text into lines and computes their nesting levels: the brace depth at the
+// start of the line for brace languages, the indentation width for indent languages
+func newCodeLines(text, blocks string) *codeLines {
+	lines := &codeLines{text: text}
+	for start := 0; start < len(text); {
+		lines.starts = append(lines.starts, start)
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			break
+		}
+		start += end + 1
+	}
+
+	if blocks == codeBlocksIndent {
+		lines.levels = indentLevels(text, lines.starts)
+	} else {
+		lines.levels = braceLevels(text, lines.starts)
+	}
+	return lines
+}
+
+// line returns the text of line i without its line break
+func (l *codeLines) line(i int) string {
+	return strings.TrimRight(l.text[l.starts[i]:l.end(i)], "\r\n")
+}
+
+// end returns the byte offset after line i, including its line break
+func (l *codeLines) end(i int) int {
+	if i+1 < len(l.starts) {
+		return l.starts[i+1]
+	}
+	return len(l.text)
+}
+
+// span returns the text span of lines [from, to)
+func (l *codeLines) span(from, to int) textSpan {
+	return textSpan{start: l.starts[from], end: l.end(to - 1)}
+}
+
+// indentLevels returns the indentation width of every line, counting a tab as four columns
+func indentLevels(text string, starts []int) []int {
+	levels := make([]int, len(starts))
+	for i, start := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		line := text[start:end]
+		if strings.TrimSpace(line) == "" {
+			levels[i] = -1
+			continue
+		}
+		width := 0
+		for _, r := range line {
+			if r == ' ' {
+				width++
+			} else if r == '\t' {
+				width += 4
+			} else {
+				break
+			}
+		}
+		levels[i] = width
+	}
+	return levels
+}
+
+// braceLevels returns the curly brace depth at the start of every line. Braces in comments,
+// string and character literals are skipped.
+func braceLevels(text string, starts []int) []int {
+	levels := make([]int, len(starts))
+	depth := 0
+	inBlockComment := false
+	inTemplate := false
+	for i, start := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		line := text[start:end]
+		if strings.TrimSpace(line) == "" && !inBlockComment && !inTemplate {
+			levels[i] = -1
+			continue
+		}
+		levels[i] = depth
+
+		for j := 0; j < len(line); j++ {
+			switch {
+			case inBlockComment:
+				if strings.HasPrefix(line[j:], "*/") {
+					inBlockComment = false
+					j++
+				}
+			case inTemplate:
+				if line[j] == '\\' {
+					j++
+				} else if line[j] == '`' {
+					inTemplate = false
+				}
+			case strings.HasPrefix(line[j:], "//"):
+				j = len(line)
+			case strings.HasPrefix(line[j:], "/*"):
+				inBlockComment = true
+				j++
+			case line[j] == '`':
+				inTemplate = true
+			case line[j] == '"':
+				j = skipQuoted(line, j, '"')
+			case line[j] == '\'':
+				// Only short literals such as '{' or '\n'; a lone quote may be a Rust lifetime
+				if closing := skipQuoted(line, j, '\''); closing-j <= 3 && closing < len(line) {
+					j = closing
+				}
+			case line[j] == '{':
+				depth++
+			case line[j] == '}':
+				depth = max(depth-1, 0)
+			}
+		}
+	}
+	return levels
+}
+
+// skipQuoted returns the index of the quote closing the literal opened at line[open],
+// or the last index of the line if it is not closed
+func skipQuoted(line string, open int, quote byte) int {
+	for j := open + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case quote:
+			return j
+		case '\n':
+			return j
+		}
+	}
+	return len(line) - 1
+}
+
+// blockSpans splits lines [from, to) into blocks at the given nesting level. Blocks longer than
+// the chunk size are split into their nested blocks, whose symbols are qualified by the parent.
+func (c *CodeChunker) blockSpans(text string, lines *codeLines, from, to, level int, parent string) []codeSpan {
+	var spans []codeSpan
+	for _, block := range splitCodeBlocks(lines, from, to, level) {
+		span := lines.span(block[0], block[1])
+		symbol := blockSymbol(lines, block[0], block[1])
+		// Blocks without a symbol, such as package and import statements, are joined while they fit
+		if last := len(spans) - 1; symbol == "" && last >= 0 && spans[last].symbol == qualifySymbol(parent, "") &&
+			spanLength(text, textSpan{start: spans[last].start, end: span.end}) <= c.chunkSize {
+			spans[last].end = span.end
+			continue
+		}
+		symbol = qualifySymbol(parent, symbol)
+		if c.chunkSize <= 0 || spanLength(text, span) <= c.chunkSize {
+			spans = append(spans, codeSpan{textSpan: span, symbol: symbol})
+			continue
+		}
+
+		// Descend into the body: the lines nested deeper than the block itself
+		inner, first := -1, -1
+		for i := block[0]; i < block[1]; i++ {
+			if lines.levels[i] > level && (inner < 0 || lines.levels[i] < inner) {
+				inner = lines.levels[i]
+				if first < 0 {
+					first = i
+				}
+			}
+		}
+		if inner < 0 {
+			spans = append(spans, c.splitOversized(text, span, symbol)...)
+			continue
+		}
+
+		nested := c.blockSpans(text, lines, first, block[1], inner, symbol)
+		// The block header, such as "class Foo {", starts the first nested chunk
+		nested[0].start = span.start
+		spans = append(spans, nested...)
+	}
+	return spans
+}
+
+// splitCodeBlocks splits lines [from, to) into blocks at the given nesting level. A block starts
+// at a line on that level that follows a blank line or a more deeply nested line, so that a
+// function with its doc comment or decorators, or a run of imports, stays in one block.
+// Lines starting with a closing bracket never start a block.
+func splitCodeBlocks(lines *codeLines, from, to, level int) [][2]int {
+	var blocks [][2]int
+	start := from
+	previous := -1
+	blank := false
+	for i := from; i < to; i++ {
+		if lines.levels[i] < 0 {
+			blank = previous >= 0
+			continue
+		}
+
+		line := strings.TrimSpace(lines.line(i))
+		closing := strings.HasPrefix(line, "}") || strings.HasPrefix(line, ")") || strings.HasPrefix(line, "]")
+		if previous >= 0 && lines.levels[i] <= level && (previous > level || blank) && !closing {
+			blocks = append(blocks, [2]int{start, i})
+			start = i
+		}
+		previous = lines.levels[i]
+		blank = false
+	}
+	return append(blocks, [2]int{start, to})
+}
+
+// symbolPatterns find the declared name in the first code line of a block
+var symbolPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:class|interface|struct|enum|trait|impl|fn|def|func|function|type|object|module|namespace|record|message|service)\s+([A-Za-z_$][\w$]*)`),
+	regexp.MustCompile(`\b(?:const|let|var|val)\s+([A-Za-z_$][\w$]*)\s*[:=]`),
+	regexp.MustCompile(`([A-Za-z_$][\w$]*)\s*\(`),
+}
+
+// notSymbols are keywords that the call-like pattern must not take for a symbol
+var notSymbols = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true, "catch": true,
+	"require": true, "import": true, "include": true,
+}
+
+// blockSymbol returns the name declared by a block, skipping comments, decorators and annotations
+func blockSymbol(lines *codeLines, from, to int) string {
+	for i := from; i < to; i++ {
+		line := strings.TrimSpace(lines.line(i))
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") ||
+			strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") ||
+			strings.HasPrefix(line, "<?") {
+			continue
+		}
+		for _, pattern := range symbolPatterns {
+			if match := pattern.FindStringSubmatch(line); match != nil && !notSymbols[match[1]] {
+				return match[1]
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// qualifySymbol prefixes a nested symbol with the symbol of its parent block
+func qualifySymbol(parent, symbol string) string {
+	switch {
+	case parent == "":
+		return symbol
+	case symbol == "":
+		return parent
+	}
+	return parent + "." + symbol
+}
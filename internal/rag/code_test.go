@@ -0,0 +1,241 @@
+package rag
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+const goSource = `// Package demo is a demo.
+package demo
+
+import "fmt"
+
+// Greeter greets people.
+type Greeter struct {
+	name string
+}
+
+// Greet greets.
+func (g *Greeter) Greet() string {
+	return fmt.Sprintf("hi %s", g.name)
+}
+
+const (
+	A = 1
+	B = 2
+)
+
+func main() {
+	fmt.Println(A)
+}
+// trailing comment
+`
+
+const javaSource = `package demo;
+
+import java.util.List;
+
+/** Users. */
+public class UserService {
+    private final List<String> users;
+
+    public String findById(int id) {
+        return users.get(id);
+    }
+
+    @Override
+    public String toString() {
+        return "UserService{" + users + "}";
+    }
+}
+`
+
+const pythonSource = `import os
+
+
+@dataclass
+class Config:
+    path: str
+
+    def load(self):
+        return os.path.join(self.path, "x")
+
+
+def main():
+    print(Config("a").load())
+`
+
+// codeChunk is the part of a chunk checked by the code chunker tests
+type codeChunk struct {
+	Symbol    string
+	StartLine int
+	EndLine   int
+	First     string
+}
+
+func TestCodeChunker_ChunkFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunkSize int
+		path      string
+		text      string
+		want      []codeChunk
+	}{
+		{
+			name:      "go declarations",
+			chunkSize: 1000,
+			path:      "demo/main.go",
+			text:      goSource,
+			want: []codeChunk{
+				{Symbol: "package demo", StartLine: 1, EndLine: 4, First: "// Package demo is a demo."},
+				{Symbol: "Greeter", StartLine: 6, EndLine: 9, First: "// Greeter greets people."},
+				{Symbol: "Greeter.Greet", StartLine: 11, EndLine: 14, First: "// Greet greets."},
+				{Symbol: "A, B", StartLine: 16, EndLine: 19, First: "const ("},
+				{Symbol: "main", StartLine: 21, EndLine: 24, First: "func main() {"},
+			},
+		},
+		{
+			name:      "go without path is detected by its package clause",
+			chunkSize: 1000,
+			text:      "package demo\n\nfunc One() {}\n\nfunc Two[T any](t T) {}\n",
+			want: []codeChunk{
+				{Symbol: "package demo", StartLine: 1, EndLine: 1, First: "package demo"},
+				{Symbol: "One", StartLine: 3, EndLine: 3, First: "func One() {}"},
+				{Symbol: "Two", StartLine: 5, EndLine: 5, First: "func Two[T any](t T) {}"},
+			},
+		},
+		{
+			name:      "long go declaration is split at lines",
+			chunkSize: 40,
+			path:      "main.go",
+			text:      "package demo\n\nfunc main() {\n\tprintln(\"first line\")\n\tprintln(\"second line\")\n}\n",
+			want: []codeChunk{
+				{Symbol: "package demo", StartLine: 1, EndLine: 1, First: "package demo"},
+				{Symbol: "main", StartLine: 3, EndLine: 4, First: "func main() {"},
+				{Symbol: "main", StartLine: 5, EndLine: 6, First: "\tprintln(\"second line\")"},
+			},
+		},
+		{
+			name:      "java class within chunk size",
+			chunkSize: 1000,
+			path:      "UserService.java",
+			text:      javaSource,
+			want: []codeChunk{
+				{Symbol: "", StartLine: 1, EndLine: 3, First: "package demo;"},
+				{Symbol: "UserService", StartLine: 5, EndLine: 17, First: "/** Users. */"},
+			},
+		},
+		{
+			name:      "large java class is split into methods",
+			chunkSize: 150,
+			path:      "UserService.java",
+			text:      javaSource,
+			want: []codeChunk{
+				{Symbol: "", StartLine: 1, EndLine: 3, First: "package demo;"},
+				{Symbol: "UserService", StartLine: 5, EndLine: 7, First: "/** Users. */"},
+				{Symbol: "UserService.findById", StartLine: 9, EndLine: 11, First: "    public String findById(int id) {"},
+				{Symbol: "UserService.toString", StartLine: 13, EndLine: 17, First: "    @Override"},
+			},
+		},
+		{
+			name:      "braces in strings and comments are skipped",
+			chunkSize: 1000,
+			path:      "app.js",
+			text:      "const open = \"{\"; // {\n\nfunction render() {\n  return `}`;\n}\n\nexport class App {}\n",
+			want: []codeChunk{
+				{Symbol: "open", StartLine: 1, EndLine: 1, First: "const open = \"{\"; // {"},
+				{Symbol: "render", StartLine: 3, EndLine: 5, First: "function render() {"},
+				{Symbol: "App", StartLine: 7, EndLine: 7, First: "export class App {}"},
+			},
+		},
+		{
+			name:      "python by indentation",
+			chunkSize: 70,
+			path:      "config.py",
+			text:      pythonSource,
+			want: []codeChunk{
+				{Symbol: "", StartLine: 1, EndLine: 1, First: "import os"},
+				{Symbol: "Config", StartLine: 4, EndLine: 6, First: "@dataclass"},
+				{Symbol: "Config.load", StartLine: 8, EndLine: 9, First: "    def load(self):"},
+				{Symbol: "main", StartLine: 12, EndLine: 13, First: "def main():"},
+			},
+		},
+		{
+			name:      "other files are chunked recursively",
+			chunkSize: 20,
+			path:      "notes.txt",
+			text:      "First paragraph.\n\nSecond paragraph.",
+			want: []codeChunk{
+				{First: "First paragraph."},
+				{First: "Second paragraph."},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := NewCodeChunker(tt.chunkSize, 0).ChunkFile(tt.path, tt.text)
+
+			var got []codeChunk
+			for _, chunk := range chunks {
+				first, _, _ := strings.Cut(chunk.Text, "\n")
+				got = append(got, codeChunk{Symbol: chunk.Symbol, StartLine: chunk.StartLine, EndLine: chunk.EndLine, First: first})
+
+				if chunk.StartLine > 0 {
+					lines := strings.Split(tt.text, "\n")[chunk.StartLine-1 : chunk.EndLine]
+					if source := strings.TrimSpace(strings.Join(lines, "\n")); source != strings.TrimSpace(chunk.Text) {
+						t.Errorf("chunk %q has lines %d-%d holding %q", chunk.Text, chunk.StartLine, chunk.EndLine, source)
+					}
+					if chunk.FilePath != tt.path {
+						t.Errorf("chunk %q has file path %q, want %q", chunk.Text, chunk.FilePath, tt.path)
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChunkFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_CodeChunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	text := "package demo\n\n// Run runs.\nfunc Run() {}\n"
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"package demo", "// Run runs.\nfunc Run() {}"}).
+		Return([][]float32{{1, 0}, {0, 1}}, nil)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "run").Return([]float32{0, 1}, nil)
+
+	ctx := context.Background()
+	pipeline, err := NewPipeline(NewCodeChunker(1000, 0), mockLLM, newTestMemoryStore(t, ""), 2, 1)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	if err := pipeline.Ingest(ctx, text, "demo", map[string]any{"source": "demo/run.go"}); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	results, err := pipeline.Retrieve(ctx, "run", RetrieveOptions{})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Retrieve() returned %d results, want 1", len(results))
+	}
+	got := results[0]
+	if got.FilePath != "demo/run.go" || got.Symbol != "Run" || got.StartLine != 3 || got.EndLine != 4 {
+		t.Errorf("Retrieve() = %+v, want Run at demo/run.go:3-4", got)
+	}
+
+	wantContext := "[Document 1, Score: 1.0000, File: demo/run.go:3-4, Symbol: Run]\n// Run runs.\nfunc Run() {}"
+	if got := BuildContext(results); got != wantContext {
+		t.Errorf("BuildContext() = %q, want %q", got, wantContext)
+	}
+}
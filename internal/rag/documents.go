@@ -0,0 +1,157 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/qdrant/go-client/qdrant"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// ErrDocumentNotFound is returned when no chunks are stored for a document ID
+var ErrDocumentNotFound = errors.New("document not found")
+
+// documentScrollPageSize is the page size used when reading all chunks of a document
+const documentScrollPageSize = 256
+
+// ListDocuments returns a page of ingested documents starting at the given offset.
+// Documents are enumerated by their first chunk, so every document appears exactly once,
+// and their chunk count is read from it.
+func (p *Pipeline) ListDocuments(ctx context.Context, limit int, offset string) (*types.DocumentListResponse, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+
+	var offsetID *qdrant.PointId
+	if offset != "" {
+		offsetID = parsePointID(offset)
+	}
+
+	// Only first chunks of named documents
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatchInt("chunk_index", 0),
+		},
+		MustNot: []*qdrant.Condition{
+			qdrant.NewMatchKeyword("doc_id", ""),
+		},
+	}
+
+	points, nextOffset, err := p.qdrantClient.ScrollPoints(ctx, filter, uint32(limit), offsetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	documents := make([]types.Document, 0, len(points))
+	for _, point := range points {
+		payload := point.GetPayload()
+		docID := payload["doc_id"].GetStringValue()
+
+		// Documents ingested before the chunk count was stored are counted
+		count := payload["chunk_count"].GetIntegerValue()
+		if _, ok := payload["chunk_count"]; !ok {
+			stored, err := p.qdrantClient.CountPoints(ctx, documentFilter(docID))
+			if err != nil {
+				return nil, fmt.Errorf("failed to count chunks for document %q: %w", docID, err)
+			}
+			count = int64(stored)
+		}
+
+		documents = append(documents, types.Document{
+			ID:          docID,
+			ChunkCount:  int(count),
+			IngestedAt:  payload["ingested_at"].GetStringValue(),
+			ContentHash: payload["doc_hash"].GetStringValue(),
+		})
+	}
+
+	response := &types.DocumentListResponse{
+		Documents: documents,
+	}
+	if nextOffset != nil {
+		response.NextOffset = formatPointID(nextOffset)
+	}
+
+	return response, nil
+}
+
+// GetDocument returns a document with all of its chunks ordered by chunk index
+func (p *Pipeline) GetDocument(ctx context.Context, docID string) (*types.Document, error) {
+	if docID == "" {
+		return nil, fmt.Errorf("document ID is required")
+	}
+
+	var points []*qdrant.RetrievedPoint
+	var offset *qdrant.PointId
+	for {
+		page, nextOffset, err := p.qdrantClient.ScrollPoints(ctx, documentFilter(docID), documentScrollPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get document: %w", err)
+		}
+		points = append(points, page...)
+		if nextOffset == nil {
+			break
+		}
+		offset = nextOffset
+	}
+
+	if len(points) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+
+	chunks := make([]types.DocumentChunk, 0, len(points))
+	ingestedAt, docHash := "", ""
+	for _, point := range points {
+		payload := point.GetPayload()
+		if hash := payload["doc_hash"].GetStringValue(); hash != "" {
+			docHash = hash
+		}
+		chunks = append(chunks, types.DocumentChunk{
+			Index: int(payload["chunk_index"].GetIntegerValue()),
+			Text:  payload["text"].GetStringValue(),
+		})
+		if ts := payload["ingested_at"].GetStringValue(); ts > ingestedAt {
+			ingestedAt = ts
+		}
+	}
+
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Index < chunks[j].Index
+	})
+
+	return &types.Document{
+		ID:          docID,
+		ChunkCount:  len(chunks),
+		IngestedAt:  ingestedAt,
+		ContentHash: docHash,
+		Chunks:      chunks,
+	}, nil
+}
+
+// documentFilter matches all chunks of a document
+func documentFilter(docID string) *qdrant.Filter {
+	return &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatchKeyword("doc_id", docID),
+		},
+	}
+}
+
+// parsePointID converts a pagination offset back into a Qdrant point ID
+func parsePointID(s string) *qdrant.PointId {
+	if num, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return qdrant.NewIDNum(num)
+	}
+	return qdrant.NewID(s)
+}
+
+// formatPointID converts a Qdrant point ID into a pagination offset
+func formatPointID(id *qdrant.PointId) string {
+	if uuid := id.GetUuid(); uuid != "" {
+		return uuid
+	}
+	return strconv.FormatUint(id.GetNum(), 10)
+}
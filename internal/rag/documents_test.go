@@ -0,0 +1,233 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+func newRetrievedPoint(id *qdrant.PointId, docID string, chunkIndex int64, text, ingestedAt string) *qdrant.RetrievedPoint {
+	return &qdrant.RetrievedPoint{
+		Id: id,
+		Payload: qdrant.NewValueMap(map[string]any{
+			"text":        text,
+			"doc_id":      docID,
+			"chunk_index": chunkIndex,
+			"ingested_at": ingestedAt,
+		}),
+	}
+}
+
+func TestPipeline_ListDocuments(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          int
+		offset         string
+		setupMocks     func(*MockVectorDatabase)
+		wantErr        bool
+		errContains    string
+		wantIDs        []string
+		wantCounts     []int
+		wantNextOffset string
+	}{
+		{
+			name:  "first page with next offset",
+			limit: 2,
+			setupMocks: func(db *MockVectorDatabase) {
+				points := []*qdrant.RetrievedPoint{
+					newRetrievedPoint(qdrant.NewID(chunkPointID("doc1", 0)), "doc1", 0, "a", "2025-01-01T00:00:00Z"),
+					newRetrievedPoint(qdrant.NewID(chunkPointID("doc2", 0)), "doc2", 0, "b", "2025-01-02T00:00:00Z"),
+				}
+				points[0].Payload["chunk_count"] = qdrant.NewValueInt(5)
+				points[1].Payload["chunk_count"] = qdrant.NewValueInt(2)
+				next := qdrant.NewID(chunkPointID("doc3", 0))
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), uint32(2), nil).Return(points, next, nil)
+			},
+			wantIDs:        []string{"doc1", "doc2"},
+			wantCounts:     []int{5, 2},
+			wantNextOffset: chunkPointID("doc3", 0),
+		},
+		{
+			name:   "last page from offset counts chunks without stored count",
+			limit:  2,
+			offset: chunkPointID("doc3", 0),
+			setupMocks: func(db *MockVectorDatabase) {
+				points := []*qdrant.RetrievedPoint{
+					newRetrievedPoint(qdrant.NewID(chunkPointID("doc3", 0)), "doc3", 0, "c", "2025-01-03T00:00:00Z"),
+				}
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), uint32(2), qdrant.NewID(chunkPointID("doc3", 0))).Return(points, nil, nil)
+				db.EXPECT().CountPoints(gomock.Any(), gomock.Any()).Return(uint64(1), nil)
+			},
+			wantIDs:    []string{"doc3"},
+			wantCounts: []int{1},
+		},
+		{
+			name:        "invalid limit",
+			limit:       0,
+			setupMocks:  func(*MockVectorDatabase) {},
+			wantErr:     true,
+			errContains: "limit must be positive",
+		},
+		{
+			name:  "scroll fails",
+			limit: 2,
+			setupMocks: func(db *MockVectorDatabase) {
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), uint32(2), nil).Return(nil, nil, errors.New("scroll error"))
+			},
+			wantErr:     true,
+			errContains: "failed to list documents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockDB := NewMockVectorDatabase(ctrl)
+			mockDB.EXPECT().EnsureCollection(gomock.Any(), uint64(3072)).Return(nil)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockDB)
+			}
+
+			pipeline, err := NewPipeline(NewMockTextChunker(ctrl), NewMockLLMClient(ctrl), mockDB, 3072, 3)
+			if err != nil {
+				t.Fatalf("NewPipeline() failed: %v", err)
+			}
+
+			result, err := pipeline.ListDocuments(context.Background(), tt.limit, tt.offset)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ListDocuments() expected error but got nil")
+					return
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("ListDocuments() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ListDocuments() unexpected error: %v", err)
+			}
+
+			if len(result.Documents) != len(tt.wantIDs) {
+				t.Fatalf("ListDocuments() returned %d documents, want %d", len(result.Documents), len(tt.wantIDs))
+			}
+			for i, doc := range result.Documents {
+				if doc.ID != tt.wantIDs[i] {
+					t.Errorf("ListDocuments()[%d].ID = %q, want %q", i, doc.ID, tt.wantIDs[i])
+				}
+				if doc.ChunkCount != tt.wantCounts[i] {
+					t.Errorf("ListDocuments()[%d].ChunkCount = %d, want %d", i, doc.ChunkCount, tt.wantCounts[i])
+				}
+				if doc.IngestedAt == "" {
+					t.Errorf("ListDocuments()[%d].IngestedAt is empty", i)
+				}
+			}
+			if result.NextOffset != tt.wantNextOffset {
+				t.Errorf("ListDocuments() NextOffset = %q, want %q", result.NextOffset, tt.wantNextOffset)
+			}
+		})
+	}
+}
+
+func TestPipeline_GetDocument(t *testing.T) {
+	tests := []struct {
+		name        string
+		docID       string
+		setupMocks  func(*MockVectorDatabase)
+		wantErr     error
+		errContains string
+		wantTexts   []string
+	}{
+		{
+			name:  "chunks across pages are ordered by index",
+			docID: "doc1",
+			setupMocks: func(db *MockVectorDatabase) {
+				next := qdrant.NewID(chunkPointID("doc1", 0))
+				first := []*qdrant.RetrievedPoint{
+					newRetrievedPoint(qdrant.NewID(chunkPointID("doc1", 2)), "doc1", 2, "third", "2025-01-01T00:00:00Z"),
+				}
+				second := []*qdrant.RetrievedPoint{
+					newRetrievedPoint(qdrant.NewID(chunkPointID("doc1", 0)), "doc1", 0, "first", "2025-01-01T00:00:00Z"),
+					newRetrievedPoint(qdrant.NewID(chunkPointID("doc1", 1)), "doc1", 1, "second", "2025-01-01T00:00:00Z"),
+				}
+				gomock.InOrder(
+					db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), nil).Return(first, next, nil),
+					db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), next).Return(second, nil, nil),
+				)
+			},
+			wantTexts: []string{"first", "second", "third"},
+		},
+		{
+			name:  "document not found",
+			docID: "missing",
+			setupMocks: func(db *MockVectorDatabase) {
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), nil).Return(nil, nil, nil)
+			},
+			wantErr: ErrDocumentNotFound,
+		},
+		{
+			name:  "scroll fails",
+			docID: "doc1",
+			setupMocks: func(db *MockVectorDatabase) {
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), nil).Return(nil, nil, errors.New("scroll error"))
+			},
+			errContains: "failed to get document",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockDB := NewMockVectorDatabase(ctrl)
+			mockDB.EXPECT().EnsureCollection(gomock.Any(), uint64(3072)).Return(nil)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockDB)
+			}
+
+			pipeline, err := NewPipeline(NewMockTextChunker(ctrl), NewMockLLMClient(ctrl), mockDB, 3072, 3)
+			if err != nil {
+				t.Fatalf("NewPipeline() failed: %v", err)
+			}
+
+			doc, err := pipeline.GetDocument(context.Background(), tt.docID)
+
+			if tt.wantErr != nil || tt.errContains != "" {
+				if err == nil {
+					t.Fatalf("GetDocument() expected error but got nil")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetDocument() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("GetDocument() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("GetDocument() unexpected error: %v", err)
+			}
+
+			if doc.ChunkCount != len(tt.wantTexts) {
+				t.Errorf("GetDocument() ChunkCount = %d, want %d", doc.ChunkCount, len(tt.wantTexts))
+			}
+			for i, chunk := range doc.Chunks {
+				if chunk.Index != i || chunk.Text != tt.wantTexts[i] {
+					t.Errorf("GetDocument() chunk[%d] = {%d %q}, want {%d %q}", i, chunk.Index, chunk.Text, i, tt.wantTexts[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// Context expansion modes
+const (
+	expandNone = iota
+	// expandNeighbors adds the neighbouring chunks of a hit by chunk index
+	expandNeighbors
+	// expandParent adds the chunks of the section enclosing a hit
+	expandParent
+)
+
+// WithNeighborExpansion replaces every retrieved chunk with itself and up to window neighbouring
+// chunks of the same document on each side, so that small chunks are matched precisely but the
+// LLM sees their surroundings
+func WithNeighborExpansion(window int) PipelineOption {
+	return func(p *Pipeline) {
+		p.expansion = expandNeighbors
+		p.expansionWindow = window
+	}
+}
+
+// WithParentExpansion replaces every retrieved chunk with the section enclosing it, e.g. the
+// Markdown section or code declaration it was split from. A positive window limits the section
+// to that many chunks on each side of the hit.
+func WithParentExpansion(window int) PipelineOption {
+	return func(p *Pipeline) {
+		p.expansion = expandParent
+		p.expansionWindow = window
+	}
+}
+
+// parentRanges assigns every chunk its parent section: the run of consecutive chunks with the same
+// heading path, file and symbol. The ranges hold chunk indices, end exclusive. It returns nil when
+// no chunk has structure, so that such documents are expanded to neighbours rather than as a whole.
+func parentRanges(chunks []Chunk) [][2]int {
+	if !slices.ContainsFunc(chunks, func(chunk Chunk) bool {
+		return chunk.HeadingPath != "" || chunk.FilePath != "" || chunk.Symbol != ""
+	}) {
+		return nil
+	}
+
+	ranges := make([][2]int, len(chunks))
+	start := 0
+	for i := range chunks {
+		if i > 0 && !sameSection(chunks[i-1], chunks[i]) {
+			start = i
+		}
+		ranges[i][0] = start
+	}
+	end := len(chunks)
+	for i := len(chunks) - 1; i >= 0; i-- {
+		ranges[i][1] = end
+		if ranges[i][0] == i {
+			end = i
+		}
+	}
+	return ranges
+}
+
+// sameSection reports whether two consecutive chunks belong to the same section
+func sameSection(a, b Chunk) bool {
+	return a.HeadingPath == b.HeadingPath && a.FilePath == b.FilePath && a.Symbol == b.Symbol
+}
+
+// contextRange returns the chunk indices [start, end) that replace a hit in the context
+func (p *Pipeline) contextRange(result SearchResult) (int, int) {
+	start, end := result.ChunkIndex-p.expansionWindow, result.ChunkIndex+p.expansionWindow+1
+	// Chunks ingested without parent references are expanded to their neighbours
+	if p.expansion == expandParent && result.ParentEnd > result.ParentStart {
+		if p.expansionWindow <= 0 {
+			return result.ParentStart, result.ParentEnd
+		}
+		start, end = max(start, result.ParentStart), min(end, result.ParentEnd)
+	}
+	return max(start, 0), end
+}
+
+// expandedResult is a hit together with the chunk range it is expanded to
+type expandedResult struct {
+	result     SearchResult
+	start, end int
+}
+
+// expandResults replaces the text of every hit with the text of its context range. Hits of the same
+// document whose ranges overlap are merged into the best ranked one, so no chunk is sent twice.
+// The other fields, such as the chunk index and offsets, still describe the matched chunk.
+func (p *Pipeline) expandResults(ctx context.Context, results []SearchResult) ([]SearchResult, error) {
+	expanded := make([]*expandedResult, len(results))
+	for i, result := range results {
+		expanded[i] = &expandedResult{result: result, start: result.ChunkIndex, end: result.ChunkIndex + 1}
+		if result.DocID != "" {
+			expanded[i].start, expanded[i].end = p.contextRange(result)
+		}
+	}
+	expanded = mergeOverlapping(expanded)
+
+	expandedResults := make([]SearchResult, 0, len(expanded))
+	for _, hit := range expanded {
+		if hit.result.DocID != "" && hit.end-hit.start > 1 {
+			text, err := p.rangeText(ctx, hit.result.DocID, hit.start, hit.end)
+			if err != nil {
+				return nil, err
+			}
+			if text != "" {
+				hit.result.Text = text
+			}
+		}
+		expandedResults = append(expandedResults, hit.result)
+	}
+	return expandedResults, nil
+}
+
+// mergeOverlapping merges every hit into the first hit of the same document whose range overlaps it
+func mergeOverlapping(hits []*expandedResult) []*expandedResult {
+	for i := 0; i < len(hits); i++ {
+		for j := i + 1; j < len(hits); {
+			a, b := hits[i], hits[j]
+			if a.result.DocID == "" || a.result.DocID != b.result.DocID || a.end <= b.start || b.end <= a.start {
+				j++
+				continue
+			}
+			a.start, a.end = min(a.start, b.start), max(a.end, b.end)
+			hits = append(hits[:j], hits[j+1:]...)
+			// The widened range may now overlap hits that were checked before
+			j = i + 1
+		}
+	}
+	return hits
+}
+
+// rangeText fetches the chunks [start, end) of a document and joins their texts in order,
+// dropping the text that consecutive chunks share. The overlap is taken from the offsets or,
+// for chunks ingested with an overlap but without offsets, from the words that repeat at the
+// chunk boundary.
+func (p *Pipeline) rangeText(ctx context.Context, docID string, start, end int) (string, error) {
+	gte, lt := float64(start), float64(end)
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatchKeyword("doc_id", docID),
+			qdrant.NewRange("chunk_index", &qdrant.Range{Gte: &gte, Lt: &lt}),
+		},
+	}
+	points, _, err := p.qdrantClient.ScrollPoints(ctx, filter, uint32(end-start), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch context chunks: %w", err)
+	}
+
+	chunks := make([]SearchResult, len(points))
+	for i, point := range points {
+		chunks[i] = newSearchResult(point.GetPayload(), 0)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].ChunkIndex < chunks[j].ChunkIndex
+	})
+
+	texts := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		text := chunk.Text
+		if i > 0 && chunk.ChunkIndex == chunks[i-1].ChunkIndex+1 {
+			previous := chunks[i-1]
+			if chunk.Offsets != nil && previous.Offsets != nil {
+				if overlap := previous.Offsets.End - chunk.Offsets.Start; overlap > 0 {
+					text = strings.TrimSpace(dropRunes(text, overlap))
+				}
+			} else if chunk.chunkOverlap > 0 {
+				text = trimOverlapWords(previous.Text, text)
+			}
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// minOverlapWords is the shortest run of words repeated at a chunk boundary that is taken for
+// a chunk overlap; a single repeated word is more likely part of the text
+const minOverlapWords = 2
+
+// trimOverlapWords removes the longest run of leading words of text that also ends previous
+func trimOverlapWords(previous, text string) string {
+	previousWords, words := strings.Fields(previous), strings.Fields(text)
+	for n := min(len(previousWords), len(words)); n >= minOverlapWords; n-- {
+		if slices.Equal(previousWords[len(previousWords)-n:], words[:n]) {
+			for range n {
+				text = strings.TrimLeftFunc(text, unicode.IsSpace)
+				text = strings.TrimLeftFunc(text, func(r rune) bool { return !unicode.IsSpace(r) })
+			}
+			return strings.TrimSpace(text)
+		}
+	}
+	return text
+}
+
+// dropRunes removes the first n runes of text
+func dropRunes(text string, n int) string {
+	for i := 0; i < n && text != ""; i++ {
+		_, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+	}
+	return text
+}
@@ -0,0 +1,242 @@
+package rag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// fixedChunker returns the same chunks for every text
+type fixedChunker []Chunk
+
+func (c fixedChunker) ChunkText(text string) []string {
+	return nil
+}
+
+func (c fixedChunker) Chunk(text string) []Chunk {
+	return c
+}
+
+func TestParentRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []Chunk
+		want   [][2]int
+	}{
+		{
+			name:   "no structure has no sections",
+			chunks: []Chunk{{Text: "a"}, {Text: "b"}, {Text: "c"}},
+			want:   nil,
+		},
+		{
+			name: "headings",
+			chunks: []Chunk{
+				{HeadingPath: "Pods"}, {HeadingPath: "Pods > Lifecycle"}, {HeadingPath: "Pods > Lifecycle"}, {HeadingPath: "Pods"},
+			},
+			want: [][2]int{{0, 1}, {1, 3}, {1, 3}, {3, 4}},
+		},
+		{
+			name: "code symbols",
+			chunks: []Chunk{
+				{FilePath: "main.go", Symbol: "main"}, {FilePath: "main.go", Symbol: "main"}, {FilePath: "main.go", Symbol: "run"},
+			},
+			want: [][2]int{{0, 2}, {0, 2}, {2, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parentRanges(tt.chunks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parentRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimOverlapWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		text     string
+		want     string
+	}{
+		{
+			name:     "overlapping words are dropped",
+			previous: "one two three four",
+			text:     "three four five six",
+			want:     "five six",
+		},
+		{
+			name:     "a single repeated word is kept",
+			previous: "mount the",
+			text:     "the volume",
+			want:     "the volume",
+		},
+		{
+			name:     "no overlap",
+			previous: "one two",
+			text:     "three four",
+			want:     "three four",
+		},
+		{
+			name:     "whole chunk overlaps",
+			previous: "one two three",
+			text:     "two three",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimOverlapWords(tt.previous, tt.text); got != tt.want {
+				t.Errorf("trimOverlapWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_ContextExpansion(t *testing.T) {
+	// Sections A (chunks 0-2), B (chunks 3-4) and C (chunk 5); the query matches chunks 2 and 4
+	sections := fixedChunker{
+		{Text: "a0", HeadingPath: "A"}, {Text: "a1", HeadingPath: "A"}, {Text: "a2", HeadingPath: "A"},
+		{Text: "b3", HeadingPath: "B"}, {Text: "b4", HeadingPath: "B"},
+		{Text: "c5", HeadingPath: "C"},
+	}
+	sectionEmbeddings := [][]float32{{0, 1}, {0, 1}, {1, 0}, {0, 1}, {1, 0.1}, {0, 1}}
+
+	// Chunks with overlapping offsets, as produced with a chunk overlap; the query matches chunk 1
+	overlapping := fixedChunker{
+		{Text: "alpha beta", Offsets: &types.TextRange{Start: 0, End: 10}},
+		{Text: "beta gamma", Offsets: &types.TextRange{Start: 6, End: 16}},
+		{Text: "gamma delta", Offsets: &types.TextRange{Start: 11, End: 22}},
+	}
+	overlappingEmbeddings := [][]float32{{0, 1}, {1, 0}, {0, 1}}
+
+	// Chunks without structure or offsets, as produced by the fixed chunker with a word overlap;
+	// the query matches chunk 2
+	unstructured := fixedChunker{
+		{Text: "one two three"}, {Text: "two three four five"}, {Text: "four five six seven"}, {Text: "six seven eight"},
+	}
+	unstructuredEmbeddings := [][]float32{{0, 1}, {0, 1}, {1, 0}, {0, 1}}
+
+	// Chunks without overlap whose boundaries repeat words, "the cluster"; the query matches chunk 1
+	repeated := fixedChunker{
+		{Text: "deploy the app to the cluster"}, {Text: "the cluster restarts failed pods"}, {Text: "failed pods are logged"},
+	}
+	repeatedEmbeddings := [][]float32{{0, 1}, {1, 0}, {0, 1}}
+
+	tests := []struct {
+		name       string
+		chunker    fixedChunker
+		embeddings [][]float32
+		option     PipelineOption
+		// chunkOverlap is the chunk overlap recorded at ingest
+		chunkOverlap int
+		want         []string
+		// wantIndex is the chunk index of the best hit, which expansion keeps
+		wantIndex int
+	}{
+		{
+			name:       "no expansion",
+			chunker:    sections,
+			embeddings: sectionEmbeddings,
+			want:       []string{"a2", "b4"},
+			wantIndex:  2,
+		},
+		{
+			name:       "overlapping neighbours are merged",
+			chunker:    sections,
+			embeddings: sectionEmbeddings,
+			option:     WithNeighborExpansion(1),
+			want:       []string{"a1\na2\nb3\nb4\nc5"},
+			wantIndex:  2,
+		},
+		{
+			name:       "whole parent sections",
+			chunker:    sections,
+			embeddings: sectionEmbeddings,
+			option:     WithParentExpansion(0),
+			want:       []string{"a0\na1\na2", "b3\nb4"},
+			wantIndex:  2,
+		},
+		{
+			name:       "parent sections within the window",
+			chunker:    sections,
+			embeddings: sectionEmbeddings,
+			option:     WithParentExpansion(1),
+			want:       []string{"a1\na2", "b3\nb4"},
+			wantIndex:  2,
+		},
+		{
+			name:       "overlapping text is sent once",
+			chunker:    overlapping,
+			embeddings: overlappingEmbeddings,
+			option:     WithNeighborExpansion(1),
+			want:       []string{"alpha beta\ngamma\ndelta"},
+			wantIndex:  1,
+		},
+		{
+			name:         "parent expansion without structure falls back to neighbours",
+			chunker:      unstructured,
+			embeddings:   unstructuredEmbeddings,
+			option:       WithParentExpansion(1),
+			chunkOverlap: 2,
+			want:         []string{"two three four five\nsix seven\neight"},
+			wantIndex:    2,
+		},
+		{
+			name:       "words repeated at boundaries are kept without chunk overlap",
+			chunker:    repeated,
+			embeddings: repeatedEmbeddings,
+			option:     WithNeighborExpansion(1),
+			want:       []string{"deploy the app to the cluster\nthe cluster restarts failed pods\nfailed pods are logged"},
+			wantIndex:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLLM := NewMockLLMClient(ctrl)
+			mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return(tt.embeddings, nil)
+			mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "query").Return([]float32{1, 0}, nil)
+
+			var opts []PipelineOption
+			if tt.option != nil {
+				opts = append(opts, tt.option)
+			}
+			if tt.chunkOverlap > 0 {
+				opts = append(opts, WithChunkOverlap(tt.chunkOverlap))
+			}
+			ctx := context.Background()
+			pipeline, err := NewPipeline(tt.chunker, mockLLM, newTestMemoryStore(t, ""), 2, 2, append(opts, WithMinScore(0.5))...)
+			if err != nil {
+				t.Fatalf("NewPipeline() unexpected error: %v", err)
+			}
+			if err := pipeline.Ingest(ctx, "text", "doc", nil); err != nil {
+				t.Fatalf("Ingest() unexpected error: %v", err)
+			}
+
+			results, err := pipeline.Retrieve(ctx, "query", RetrieveOptions{})
+			if err != nil {
+				t.Fatalf("Retrieve() unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, result := range results {
+				got = append(got, result.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Retrieve() texts = %q, want %q", got, tt.want)
+			}
+			if results[0].ChunkIndex != tt.wantIndex {
+				t.Errorf("Retrieve() chunk index = %d, want %d", results[0].ChunkIndex, tt.wantIndex)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/qdrant/go-client/qdrant"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrInvalidMetadata is returned when document metadata cannot be stored in the payload
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// reservedPayloadKeys are payload fields set by the pipeline that metadata must not overwrite
+var reservedPayloadKeys = []string{"text", "doc_id", "chunk_index", "ingested_at", "heading_path", "start_offset", "end_offset",
+	"file_path", "symbol", "start_line", "end_line", "parent_start", "parent_end", "chunk_hash", "doc_hash", "chunk_count", "chunk_overlap", "embedding_model"}
+
+// validateMetadata checks that document metadata does not collide with pipeline payload fields
+func validateMetadata(metadata map[string]any) error {
+	for _, key := range reservedPayloadKeys {
+		if _, ok := metadata[key]; ok {
+			return fmt.Errorf("%w: key %q is reserved", ErrInvalidMetadata, key)
+		}
+	}
+	return nil
+}
+
+// buildQueryFilter translates a query filter into a Qdrant filter; it returns nil for an empty filter
+func buildQueryFilter(filter *types.QueryFilter) *qdrant.Filter {
+	if filter == nil {
+		return nil
+	}
+
+	var must []*qdrant.Condition
+	if len(filter.DocIDs) > 0 {
+		must = append(must, qdrant.NewMatchKeywords("doc_id", filter.DocIDs...))
+	}
+	if len(filter.Tags) > 0 {
+		must = append(must, qdrant.NewMatchKeywords("tags", filter.Tags...))
+	}
+	if filter.Source != "" {
+		must = append(must, qdrant.NewMatchKeyword("source", filter.Source))
+	}
+	if filter.IngestedAt != nil {
+		must = append(must, dateRangeCondition("ingested_at", *filter.IngestedAt))
+	}
+
+	// Sort fields for a deterministic filter
+	fields := make([]string, 0, len(filter.Dates))
+	for field := range filter.Dates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		must = append(must, dateRangeCondition(field, filter.Dates[field]))
+	}
+
+	if len(must) == 0 {
+		return nil
+	}
+	return &qdrant.Filter{Must: must}
+}
+
+// dateRangeCondition builds an inclusive datetime range condition on a payload field
+func dateRangeCondition(field string, dateRange types.DateRange) *qdrant.Condition {
+	r := &qdrant.DatetimeRange{}
+	if dateRange.From != nil {
+		r.Gte = timestamppb.New(*dateRange.From)
+	}
+	if dateRange.To != nil {
+		r.Lte = timestamppb.New(*dateRange.To)
+	}
+	return qdrant.NewDatetimeRange(field, r)
+}
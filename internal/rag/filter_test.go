@@ -0,0 +1,140 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdrant/go-client/qdrant"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+func TestBuildQueryFilter(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	payload := map[string]*qdrant.Value{
+		"doc_id":       qdrant.NewValueString("runbook"),
+		"source":       qdrant.NewValueString("wiki"),
+		"tags":         qdrant.NewValueFromList(qdrant.NewValueString("team-a"), qdrant.NewValueString("oncall")),
+		"ingested_at":  qdrant.NewValueString("2025-03-01T12:00:00Z"),
+		"published_at": qdrant.NewValueString("2024-06-01T00:00:00Z"),
+	}
+
+	tests := []struct {
+		name       string
+		filter     *types.QueryFilter
+		wantNil    bool
+		wantMatch  bool
+		conditions int
+	}{
+		{
+			name:    "nil filter",
+			wantNil: true,
+		},
+		{
+			name:    "empty filter",
+			filter:  &types.QueryFilter{},
+			wantNil: true,
+		},
+		{
+			name:       "doc IDs match any",
+			filter:     &types.QueryFilter{DocIDs: []string{"faq", "runbook"}},
+			wantMatch:  true,
+			conditions: 1,
+		},
+		{
+			name:       "tags match any",
+			filter:     &types.QueryFilter{Tags: []string{"team-b", "team-a"}},
+			wantMatch:  true,
+			conditions: 1,
+		},
+		{
+			name:       "source mismatch",
+			filter:     &types.QueryFilter{Source: "jira", Tags: []string{"team-a"}},
+			wantMatch:  false,
+			conditions: 2,
+		},
+		{
+			name:       "ingested after",
+			filter:     &types.QueryFilter{IngestedAt: &types.DateRange{From: &from}},
+			wantMatch:  true,
+			conditions: 1,
+		},
+		{
+			name:       "metadata date before range",
+			filter:     &types.QueryFilter{Dates: map[string]types.DateRange{"published_at": {From: &from}}},
+			wantMatch:  false,
+			conditions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := buildQueryFilter(tt.filter)
+
+			if tt.wantNil {
+				if filter != nil {
+					t.Errorf("buildQueryFilter() = %v, want nil", filter)
+				}
+				return
+			}
+
+			if len(filter.GetMust()) != tt.conditions {
+				t.Errorf("buildQueryFilter() has %d conditions, want %d", len(filter.GetMust()), tt.conditions)
+			}
+
+			match, err := matchFilter(filter, payload)
+			if err != nil {
+				t.Fatalf("matchFilter() unexpected error: %v", err)
+			}
+			if match != tt.wantMatch {
+				t.Errorf("matchFilter(buildQueryFilter()) = %v, want %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestPipeline_Ingest_Metadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	store := newTestMemoryStore(t, "")
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{{1, 0}}, nil).Times(2)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), gomock.Any()).Return([]float32{1, 0}, nil).Times(2)
+
+	pipeline, err := NewPipeline(NewChunker(100, 0), mockLLM, store, 2, 5)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	if err := pipeline.Ingest(ctx, "Team A restarts the ingest workers", "a", map[string]any{"tags": []any{"team-a"}, "source": "wiki"}); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+	if err := pipeline.Ingest(ctx, "Team B restarts the ingest workers", "b", map[string]any{"tags": []any{"team-b"}}); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	err = pipeline.Ingest(ctx, "text", "c", map[string]any{"chunk_index": 7})
+	if !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("Ingest() with reserved key error = %v, want ErrInvalidMetadata", err)
+	}
+
+	// Dense and hybrid retrieval both honour the filter
+	weight := 0.5
+	for _, opts := range []RetrieveOptions{
+		{Filter: &types.QueryFilter{Tags: []string{"team-a"}}},
+		{Filter: &types.QueryFilter{Tags: []string{"team-a"}}, KeywordWeight: &weight},
+	} {
+		results, err := pipeline.Retrieve(ctx, "restart ingest workers", opts)
+		if err != nil {
+			t.Fatalf("Retrieve() unexpected error: %v", err)
+		}
+		if len(results) != 1 || results[0].DocID != "a" {
+			t.Errorf("Retrieve() with team-a filter = %+v, want only document a", results)
+		}
+	}
+}
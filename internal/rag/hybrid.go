@@ -0,0 +1,97 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// rrfK dampens the contribution of top ranks in reciprocal rank fusion
+const rrfK = 60
+
+// hybridCandidateFactor is how many more candidates than the search limit each retriever
+// contributes to fusion, so that a chunk ranked low by one retriever can still surface
+const hybridCandidateFactor = 4
+
+// fuseRRF merges dense and keyword rankings with weighted reciprocal rank fusion.
+// keywordWeight is the share of the keyword ranking, the dense ranking gets the rest.
+// The Score of each fused result is its fusion score.
+func fuseRRF(dense, keyword []SearchResult, keywordWeight float64, limit int) []SearchResult {
+	scores := make(map[string]float64)
+	fused := make(map[string]SearchResult)
+
+	add := func(results []SearchResult, weight float64) {
+		if weight <= 0 {
+			return
+		}
+		for rank, result := range results {
+			key := resultKey(result)
+			scores[key] += weight / float64(rrfK+rank+1)
+			if _, ok := fused[key]; !ok {
+				fused[key] = result
+			}
+		}
+	}
+	add(dense, 1-keywordWeight)
+	add(keyword, keywordWeight)
+
+	results := make([]SearchResult, 0, len(fused))
+	for key, result := range fused {
+		result.Score = float32(scores[key])
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return resultKey(results[i]) < resultKey(results[j])
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// keywordHitsAboveMinScore keeps the keyword hits whose stored vector has at least the minimum
+// cosine similarity to the query embedding, the threshold dense search applies to its hits
+func (p *Pipeline) keywordHitsAboveMinScore(ctx context.Context, queryEmbedding []float32, keyword []SearchResult) ([]SearchResult, error) {
+	if len(keyword) == 0 {
+		return keyword, nil
+	}
+
+	ids := make([]*qdrant.PointId, len(keyword))
+	for i, result := range keyword {
+		ids[i] = parsePointID(result.pointID)
+	}
+	vectors, err := p.qdrantClient.GetVectors(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keyword hit vectors: %w", err)
+	}
+
+	query := normalize(queryEmbedding)
+	kept := make([]SearchResult, 0, len(keyword))
+	for _, result := range keyword {
+		vector, ok := vectors[result.pointID]
+		if !ok || dot(query, normalize(vector)) < p.minScore {
+			continue
+		}
+		if p.mmr {
+			result.Vector = vector
+		}
+		kept = append(kept, result)
+	}
+	return kept, nil
+}
+
+// resultKey identifies a chunk across retrievers: by document and chunk index when
+// the chunk belongs to a document, by its text otherwise
+func resultKey(result SearchResult) string {
+	if result.DocID != "" {
+		return fmt.Sprintf("%s#%d", result.DocID, result.ChunkIndex)
+	}
+	return "text:" + result.Text
+}
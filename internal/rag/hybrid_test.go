@@ -0,0 +1,323 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestFuseRRF(t *testing.T) {
+	dense := []SearchResult{
+		{Text: "a", DocID: "a", Score: 0.9},
+		{Text: "b", DocID: "b", Score: 0.8},
+		{Text: "c", DocID: "c", Score: 0.7},
+	}
+	keyword := []SearchResult{
+		{Text: "c", DocID: "c", Score: 12},
+		{Text: "d", DocID: "d", Score: 7},
+	}
+
+	tests := []struct {
+		name          string
+		keywordWeight float64
+		limit         int
+		want          []string
+	}{
+		{
+			name:          "balanced fusion promotes chunks found by both",
+			keywordWeight: 0.5,
+			limit:         2,
+			want:          []string{"c", "a"},
+		},
+		{
+			name:          "keyword heavy fusion",
+			keywordWeight: 0.8,
+			limit:         3,
+			want:          []string{"c", "d", "a"},
+		},
+		{
+			name:          "dense only",
+			keywordWeight: 0,
+			limit:         2,
+			want:          []string{"a", "b"},
+		},
+		{
+			name:          "keyword only",
+			keywordWeight: 1,
+			limit:         5,
+			want:          []string{"c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := fuseRRF(dense, keyword, tt.keywordWeight, tt.limit)
+
+			got := make([]string, 0, len(results))
+			for _, result := range results {
+				got = append(got, result.DocID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("fuseRRF() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("fuseRRF() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_Retrieve_Hybrid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	store := newTestMemoryStore(t, "")
+
+	// The first chunk is semantically closest to the query, the second holds the exact identifier
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{{1, 0}}, nil)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{{0, 1}}, nil)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), gomock.Any()).Return([]float32{1, 0}, nil).Times(2)
+
+	pipeline, err := NewPipeline(NewChunker(100, 0), mockLLM, store, 2, 1)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	if err := pipeline.Ingest(ctx, "API server configuration overview", "overview", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+	if err := pipeline.Ingest(ctx, "Use --enable-admission-plugins to turn on admission plugins", "flags", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	// Dense only
+	results, err := pipeline.Retrieve(ctx, "enable-admission-plugins", RetrieveOptions{})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	if results[0].DocID != "overview" {
+		t.Errorf("Retrieve() dense = %+v, want the overview chunk", results)
+	}
+
+	// Keyword heavy
+	weight := 0.8
+	results, err = pipeline.Retrieve(ctx, "enable-admission-plugins", RetrieveOptions{KeywordWeight: &weight})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].DocID != "flags" {
+		t.Errorf("Retrieve() hybrid = %+v, want the flags chunk", results)
+	}
+
+	// Keyword only needs no query embedding
+	weight = 1
+	results, err = pipeline.Retrieve(ctx, "enable-admission-plugins", RetrieveOptions{KeywordWeight: &weight})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].DocID != "flags" {
+		t.Errorf("Retrieve() keyword = %+v, want the flags chunk", results)
+	}
+}
+
+func TestPipeline_Retrieve_HybridMinScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// The query shares the word "server" with the chunk but is semantically unrelated to it
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{{1, 0}}, nil)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "best pizza server in town").Return([]float32{0, 1}, nil).Times(2)
+
+	pipeline, err := NewPipeline(NewChunker(100, 0), mockLLM, newTestMemoryStore(t, ""), 2, 1, WithMinScore(0.5))
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	if err := pipeline.Ingest(ctx, "API server configuration overview", "overview", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	for _, weight := range []float64{0.5, 1} {
+		_, err := pipeline.Retrieve(ctx, "best pizza server in town", RetrieveOptions{KeywordWeight: &weight})
+		if !errors.Is(err, ErrNoRelevantDocuments) {
+			t.Errorf("Retrieve() with keyword weight %v error = %v, want %v", weight, err, ErrNoRelevantDocuments)
+		}
+	}
+}
+
+func TestPipeline_Retrieve_HybridKeywordMinScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// Eight chunks closest to the query fill the dense candidates; of the two chunks holding the
+	// error code, one is similar enough to the query and the other is not
+	vectors := map[string][]float32{
+		"quota errors are reported as ERR_QUOTA_EXCEEDED": {0.8, 0.6},
+		"ERR_QUOTA_EXCEEDED appears in the changelog":     {0, 1},
+	}
+	for i := range 8 {
+		vectors[fmt.Sprintf("storage overview part %d", i)] = []float32{1, 0}
+	}
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, texts []string) ([][]float32, error) {
+			embeddings := make([][]float32, len(texts))
+			for i, text := range texts {
+				embeddings[i] = vectors[text]
+			}
+			return embeddings, nil
+		},
+	).AnyTimes()
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "err_quota_exceeded").Return([]float32{1, 0}, nil).Times(2)
+
+	pipeline, err := NewPipeline(NewChunker(100, 0), mockLLM, newTestMemoryStore(t, ""), 2, 2, WithMinScore(0.5))
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	for text := range vectors {
+		if err := pipeline.Ingest(ctx, text, text, nil); err != nil {
+			t.Fatalf("Ingest() unexpected error: %v", err)
+		}
+	}
+
+	weight := 1.0
+	results, err := pipeline.Retrieve(ctx, "err_quota_exceeded", RetrieveOptions{KeywordWeight: &weight})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].DocID != "quota errors are reported as ERR_QUOTA_EXCEEDED" {
+		t.Errorf("Retrieve() keyword = %+v, want only the chunk above the minimum score", results)
+	}
+
+	// In hybrid search the keyword hit survives although it is outside the dense candidates
+	weight = 0.5
+	results, err = pipeline.Retrieve(ctx, "err_quota_exceeded", RetrieveOptions{KeywordWeight: &weight})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	found := false
+	for _, result := range results {
+		found = found || result.DocID == "quota errors are reported as ERR_QUOTA_EXCEEDED"
+		if result.DocID == "ERR_QUOTA_EXCEEDED appears in the changelog" {
+			t.Errorf("Retrieve() hybrid = %+v, want no chunk below the minimum score", results)
+		}
+	}
+	if !found {
+		t.Errorf("Retrieve() hybrid = %+v, want the keyword hit above the minimum score", results)
+	}
+}
+
+func TestPipeline_LoadKeywordIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	store := newTestMemoryStore(t, "")
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{{1, 0}}, nil)
+
+	writer, err := NewPipeline(NewChunker(100, 0), mockLLM, store, 2, 3)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	if err := writer.Ingest(ctx, "error code ERR_QUOTA_EXCEEDED", "errors", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	// A fresh pipeline over the same store starts with an empty index
+	reader, err := NewPipeline(NewChunker(100, 0), mockLLM, store, 2, 3)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	if err := reader.LoadKeywordIndex(ctx); err != nil {
+		t.Fatalf("LoadKeywordIndex() unexpected error: %v", err)
+	}
+
+	results, err := reader.keywordIndex.Search("err_quota_exceeded", 10, nil)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].DocID != "errors" {
+		t.Errorf("keyword search after LoadKeywordIndex() = %+v, want the errors chunk", results)
+	}
+}
+
+func TestPipeline_Retrieve_KeywordIndexSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	store := newTestMemoryStore(t, "")
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{{1, 0}}, nil).AnyTimes()
+
+	// Two pipelines over one collection, as the HTTP and MCP servers are; the reader is dense only
+	// by default and has never loaded its keyword index
+	writer, err := NewPipeline(NewChunker(100, 0), mockLLM, store, 2, 3)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	reader, err := NewPipeline(NewChunker(100, 0), mockLLM, store, 2, 3)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	weight := 1.0
+	search := func() []string {
+		t.Helper()
+		results, err := reader.Retrieve(ctx, "err_quota_exceeded", RetrieveOptions{KeywordWeight: &weight})
+		if errors.Is(err, ErrNoRelevantDocuments) {
+			return nil
+		}
+		if err != nil {
+			t.Fatalf("Retrieve() unexpected error: %v", err)
+		}
+		var docIDs []string
+		for _, result := range results {
+			docIDs = append(docIDs, result.DocID)
+		}
+		return docIDs
+	}
+
+	if err := writer.Ingest(ctx, "error code ERR_QUOTA_EXCEEDED", "errors", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+	if got := search(); !reflect.DeepEqual(got, []string{"errors"}) {
+		t.Errorf("keyword search with an index not loaded yet = %v, want the errors chunk", got)
+	}
+
+	if err := writer.Ingest(ctx, "ERR_QUOTA_EXCEEDED means the quota is used up", "quota", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+	if got := search(); len(got) != 2 {
+		t.Errorf("keyword search after another pipeline ingested = %v, want both chunks", got)
+	}
+
+	if err := writer.Delete(ctx, "errors"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if got := search(); !reflect.DeepEqual(got, []string{"quota"}) {
+		t.Errorf("keyword search after another pipeline deleted = %v, want the quota chunk", got)
+	}
+
+	// The same number of points, one of them rewritten by the other pipeline
+	if err := writer.Ingest(ctx, "limits are documented elsewhere", "quota", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+	if got := search(); got != nil {
+		t.Errorf("keyword search after another pipeline replaced = %v, want no chunks", got)
+	}
+}
@@ -0,0 +1,159 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/proto"
+)
+
+// storedChunk is a chunk of the previously ingested version of a document
+type storedChunk struct {
+	id      *qdrant.PointId
+	payload map[string]*qdrant.Value
+}
+
+// contentHash returns the hex-encoded SHA-256 of text
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// storedChunks returns the stored chunks of a document by chunk index
+func (p *Pipeline) storedChunks(ctx context.Context, docID string) (map[int]storedChunk, error) {
+	stored := make(map[int]storedChunk)
+	var offset *qdrant.PointId
+	for {
+		page, nextOffset, err := p.qdrantClient.ScrollPoints(ctx, documentFilter(docID), documentScrollPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range page {
+			payload := point.GetPayload()
+			stored[int(payload["chunk_index"].GetIntegerValue())] = storedChunk{id: point.GetId(), payload: payload}
+		}
+		if nextOffset == nil {
+			return stored, nil
+		}
+		offset = nextOffset
+	}
+}
+
+// unchangedDocument reports whether the stored version of a document has the same text and metadata
+// and was embedded with the same model, in which case it is not chunked again
+func unchangedDocument(stored map[int]storedChunk, docHash, embeddingModel string, metadata map[string]any) bool {
+	first, ok := stored[0]
+	if !ok || first.payload["doc_hash"].GetStringValue() != docHash || first.payload["embedding_model"].GetStringValue() != embeddingModel {
+		return false
+	}
+	values, err := qdrant.TryValueMap(metadata)
+	if err != nil {
+		return false
+	}
+
+	storedMetadata := 0
+	for key, value := range first.payload {
+		if slices.Contains(reservedPayloadKeys, key) {
+			continue
+		}
+		storedMetadata++
+		if !proto.Equal(value, values[key]) {
+			return false
+		}
+	}
+	return storedMetadata == len(values)
+}
+
+// samePayload reports whether a stored chunk payload equals a new one apart from the ingestion time
+func samePayload(stored, payload map[string]*qdrant.Value) bool {
+	if len(stored) != len(payload) {
+		return false
+	}
+	for key, value := range payload {
+		if key == "ingested_at" {
+			continue
+		}
+		if !proto.Equal(stored[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasStaleChunks reports whether the stored version of a document has chunks beyond the new chunk count
+func hasStaleChunks(stored map[int]storedChunk, chunkCount int) bool {
+	for index := range stored {
+		if index >= chunkCount {
+			return true
+		}
+	}
+	return false
+}
+
+// setVectors sets the vectors of points to upsert. A point reuses the stored vector of a chunk of
+// the previous document version with the same content hash and embedding model, e.g. a chunk that
+// moved after an insertion above it; only the remaining points are embedded.
+func (p *Pipeline) setVectors(ctx context.Context, points []*qdrant.PointStruct, stored map[int]storedChunk) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	storedIDs := make(map[string]*qdrant.PointId, len(stored))
+	for _, chunk := range stored {
+		// Vectors of another model do not share a space with new query embeddings, even if their dimension matches
+		if chunk.payload["embedding_model"].GetStringValue() != p.embeddingModel {
+			continue
+		}
+		if hash := chunk.payload["chunk_hash"].GetStringValue(); hash != "" {
+			storedIDs[hash] = chunk.id
+		}
+	}
+
+	var reuseIDs []*qdrant.PointId
+	for _, point := range points {
+		if id, ok := storedIDs[point.GetPayload()["chunk_hash"].GetStringValue()]; ok {
+			reuseIDs = append(reuseIDs, id)
+		}
+	}
+	var storedVectors map[string][]float32
+	if len(reuseIDs) > 0 {
+		var err error
+		storedVectors, err = p.qdrantClient.GetVectors(ctx, reuseIDs)
+		if err != nil {
+			return fmt.Errorf("failed to load stored vectors: %w", err)
+		}
+	}
+
+	var toEmbed []*qdrant.PointStruct
+	var texts []string
+	for _, point := range points {
+		if id, ok := storedIDs[point.GetPayload()["chunk_hash"].GetStringValue()]; ok {
+			if vector, ok := storedVectors[formatPointID(id)]; ok && len(vector) > 0 {
+				point.Vectors = qdrant.NewVectors(vector...)
+				continue
+			}
+		}
+		toEmbed = append(toEmbed, point)
+		texts = append(texts, point.GetPayload()["text"].GetStringValue())
+	}
+	if len(toEmbed) == 0 {
+		return nil
+	}
+
+	// Generate embeddings for the new chunks in batches
+	embeddings, err := p.llmClient.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(embeddings) != len(toEmbed) {
+		return fmt.Errorf("failed to generate embeddings: got %d embeddings for %d chunks", len(embeddings), len(toEmbed))
+	}
+	for i, point := range toEmbed {
+		point.Vectors = qdrant.NewVectors(embeddings[i]...)
+	}
+	return nil
+}
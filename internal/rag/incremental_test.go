@@ -0,0 +1,226 @@
+package rag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// countingStore counts the points written to a vector database
+type countingStore struct {
+	VectorDatabase
+	upserted []int64
+}
+
+func (s *countingStore) UpsertPoints(ctx context.Context, points []*qdrant.PointStruct) error {
+	for _, point := range points {
+		s.upserted = append(s.upserted, point.GetPayload()["chunk_index"].GetIntegerValue())
+	}
+	return s.VectorDatabase.UpsertPoints(ctx, points)
+}
+
+func TestPipeline_IncrementalIngest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Every text gets a distinct vector; embedded records which texts were sent to the model
+	var embedded [][]string
+	vectors := make(map[string][]float32)
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, texts []string) ([][]float32, error) {
+			embedded = append(embedded, texts)
+			embeddings := make([][]float32, len(texts))
+			for i, text := range texts {
+				vectors[text] = []float32{float32(len(vectors) + 1), 1}
+				embeddings[i] = vectors[text]
+			}
+			return embeddings, nil
+		},
+	).AnyTimes()
+
+	ctx := context.Background()
+	store := &countingStore{VectorDatabase: newTestMemoryStore(t, "")}
+	pipeline, err := NewPipeline(NewRecursiveChunker(6, 0), mockLLM, store, 2, 1)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	steps := []struct {
+		name         string
+		text         string
+		metadata     map[string]any
+		wantEmbedded []string
+		// wantUpserted are the indices of the written chunks
+		wantUpserted []int64
+		wantChunks   []string
+	}{
+		{
+			name:         "first ingest embeds all chunks",
+			text:         "alpha\n\nbeta\n\ngamma",
+			wantEmbedded: []string{"alpha", "beta", "gamma"},
+			wantUpserted: []int64{0, 1, 2},
+			wantChunks:   []string{"alpha", "beta", "gamma"},
+		},
+		{
+			name:       "unchanged document writes nothing",
+			text:       "alpha\n\nbeta\n\ngamma",
+			wantChunks: []string{"alpha", "beta", "gamma"},
+		},
+		{
+			name:         "edited first chunk is the only write",
+			text:         "omega\n\nbeta\n\ngamma",
+			wantEmbedded: []string{"omega"},
+			wantUpserted: []int64{0},
+			wantChunks:   []string{"omega", "beta", "gamma"},
+		},
+		{
+			name:         "edited chunk and the document hash are written",
+			text:         "omega\n\nbeta\n\nsigma",
+			wantEmbedded: []string{"sigma"},
+			wantUpserted: []int64{0, 2},
+			wantChunks:   []string{"omega", "beta", "sigma"},
+		},
+		{
+			name:         "inserted and removed chunks",
+			text:         "delta\n\nomega\n\nbeta",
+			wantEmbedded: []string{"delta"},
+			wantUpserted: []int64{0, 1, 2},
+			wantChunks:   []string{"delta", "omega", "beta"},
+		},
+		{
+			name:         "changed metadata embeds nothing",
+			text:         "delta\n\nomega\n\nbeta",
+			metadata:     map[string]any{"source": "wiki"},
+			wantUpserted: []int64{0, 1, 2},
+			wantChunks:   []string{"delta", "omega", "beta"},
+		},
+		{
+			name:         "shorter document",
+			text:         "delta",
+			metadata:     map[string]any{"source": "wiki"},
+			wantUpserted: []int64{0},
+			wantChunks:   []string{"delta"},
+		},
+	}
+
+	for _, step := range steps {
+		embedded = nil
+		store.upserted = nil
+		if err := pipeline.Ingest(ctx, step.text, "doc", step.metadata); err != nil {
+			t.Fatalf("%s: Ingest() unexpected error: %v", step.name, err)
+		}
+
+		var gotEmbedded []string
+		for _, texts := range embedded {
+			gotEmbedded = append(gotEmbedded, texts...)
+		}
+		if !reflect.DeepEqual(gotEmbedded, step.wantEmbedded) {
+			t.Errorf("%s: embedded %q, want %q", step.name, gotEmbedded, step.wantEmbedded)
+		}
+		if !reflect.DeepEqual(store.upserted, step.wantUpserted) {
+			t.Errorf("%s: upserted chunks %v, want %v", step.name, store.upserted, step.wantUpserted)
+		}
+
+		document, err := pipeline.GetDocument(ctx, "doc")
+		if err != nil {
+			t.Fatalf("%s: GetDocument() unexpected error: %v", step.name, err)
+		}
+		var gotChunks []string
+		for _, chunk := range document.Chunks {
+			gotChunks = append(gotChunks, chunk.Text)
+		}
+		if !reflect.DeepEqual(gotChunks, step.wantChunks) {
+			t.Errorf("%s: stored chunks %q, want %q", step.name, gotChunks, step.wantChunks)
+		}
+		if document.ContentHash != contentHash(step.text) {
+			t.Errorf("%s: content hash %q, want %q", step.name, document.ContentHash, contentHash(step.text))
+		}
+
+		// The listing reads the chunk count stored on the first chunk
+		list, err := pipeline.ListDocuments(ctx, 10, "")
+		if err != nil {
+			t.Fatalf("%s: ListDocuments() unexpected error: %v", step.name, err)
+		}
+		if len(list.Documents) != 1 || list.Documents[0].ChunkCount != len(step.wantChunks) {
+			t.Errorf("%s: listed documents %+v, want one with %d chunks", step.name, list.Documents, len(step.wantChunks))
+		}
+
+		// Moved chunks keep the vector their text was first embedded with
+		for i, text := range step.wantChunks {
+			id := qdrant.NewID(chunkPointID("doc", i))
+			stored, err := store.GetVectors(ctx, []*qdrant.PointId{id})
+			if err != nil {
+				t.Fatalf("%s: GetVectors() unexpected error: %v", step.name, err)
+			}
+			if got := stored[formatPointID(id)]; !reflect.DeepEqual(got, vectors[text]) {
+				t.Errorf("%s: chunk %d vector = %v, want %v", step.name, i, got, vectors[text])
+			}
+		}
+	}
+
+	points, _, err := store.ScrollPoints(ctx, documentFilter("doc"), 10, nil)
+	if err != nil {
+		t.Fatalf("ScrollPoints() unexpected error: %v", err)
+	}
+	if payload := points[0].GetPayload(); payload["source"].GetStringValue() != "wiki" || payload["chunk_hash"].GetStringValue() != contentHash("delta") {
+		t.Errorf("stored payload = %v, want the new metadata and the chunk hash", payload)
+	}
+}
+
+func TestPipeline_IncrementalIngest_EmbeddingModel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var embedded []string
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, texts []string) ([][]float32, error) {
+			embedded = append(embedded, texts...)
+			embeddings := make([][]float32, len(texts))
+			for i := range embeddings {
+				embeddings[i] = []float32{1, 0}
+			}
+			return embeddings, nil
+		},
+	).AnyTimes()
+
+	ctx := context.Background()
+	store := newTestMemoryStore(t, "")
+	steps := []struct {
+		name         string
+		model        string
+		wantEmbedded []string
+	}{
+		{name: "first ingest", model: "text-embedding-ada-002", wantEmbedded: []string{"alpha", "beta"}},
+		{name: "same model", model: "text-embedding-ada-002"},
+		{name: "another model of the same dimension", model: "text-embedding-3-small", wantEmbedded: []string{"alpha", "beta"}},
+	}
+
+	for _, step := range steps {
+		embedded = nil
+		pipeline, err := NewPipeline(NewRecursiveChunker(6, 0), mockLLM, store, 2, 1, WithEmbeddingModel(step.model))
+		if err != nil {
+			t.Fatalf("NewPipeline() unexpected error: %v", err)
+		}
+		if err := pipeline.Ingest(ctx, "alpha\n\nbeta", "doc", nil); err != nil {
+			t.Fatalf("%s: Ingest() unexpected error: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(embedded, step.wantEmbedded) {
+			t.Errorf("%s: embedded %q, want %q", step.name, embedded, step.wantEmbedded)
+		}
+	}
+
+	points, _, err := store.ScrollPoints(ctx, documentFilter("doc"), 10, nil)
+	if err != nil {
+		t.Fatalf("ScrollPoints() unexpected error: %v", err)
+	}
+	for _, point := range points {
+		if model := point.GetPayload()["embedding_model"].GetStringValue(); model != "text-embedding-3-small" {
+			t.Errorf("stored embedding model = %q, want %q", model, "text-embedding-3-small")
+		}
+	}
+}
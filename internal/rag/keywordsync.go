@@ -0,0 +1,133 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// keywordSync tracks whether the in-process keyword index still matches the vector database,
+// which other processes sharing the collection, such as the MCP server, may write to as well
+type keywordSync struct {
+	mu     sync.Mutex
+	loaded bool
+	// since is the start of the last load, truncated to the second precision of ingested_at
+	since time.Time
+	// written are the points this pipeline wrote since the last load, by point ID
+	written map[string]keywordPoint
+}
+
+// keywordPoint locates a point written by this pipeline
+type keywordPoint struct {
+	docID      string
+	chunkIndex int
+}
+
+// LoadKeywordIndex rebuilds the keyword index from all points stored in the vector database.
+// Hybrid queries load the index on first use and reload it when another process changed the
+// collection; calling it on startup avoids the delay on the first hybrid query.
+func (p *Pipeline) LoadKeywordIndex(ctx context.Context) error {
+	p.keywordSync.mu.Lock()
+	defer p.keywordSync.mu.Unlock()
+
+	return p.loadKeywordIndex(ctx)
+}
+
+// loadKeywordIndex rebuilds the keyword index; the caller must hold the keyword sync lock
+func (p *Pipeline) loadKeywordIndex(ctx context.Context) error {
+	since := time.Now().UTC().Truncate(time.Second)
+	index := NewBM25Index()
+	var offset *qdrant.PointId
+	for {
+		page, nextOffset, err := p.qdrantClient.ScrollPoints(ctx, nil, documentScrollPageSize, offset)
+		if err != nil {
+			return fmt.Errorf("failed to load keyword index: %w", err)
+		}
+
+		for _, point := range page {
+			payload := point.GetPayload()
+			index.Upsert(formatPointID(point.GetId()), newSearchResult(payload, 0), payload)
+		}
+
+		if nextOffset == nil {
+			break
+		}
+		offset = nextOffset
+	}
+
+	p.keywordIndex.replace(index)
+	p.keywordSync.loaded = true
+	p.keywordSync.since = since
+	p.keywordSync.written = make(map[string]keywordPoint)
+	return nil
+}
+
+// syncKeywordIndex loads the keyword index if it is not loaded yet and reloads it if the vector
+// database holds points this pipeline does not know about: a different number of points than
+// indexed, or points ingested since the last load that this pipeline did not write
+func (p *Pipeline) syncKeywordIndex(ctx context.Context) error {
+	p.keywordSync.mu.Lock()
+	defer p.keywordSync.mu.Unlock()
+
+	if !p.keywordSync.loaded {
+		return p.loadKeywordIndex(ctx)
+	}
+
+	total, err := p.qdrantClient.CountPoints(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check keyword index: %w", err)
+	}
+	recent, err := p.qdrantClient.CountPoints(ctx, &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewDatetimeRange("ingested_at", &qdrant.DatetimeRange{Gte: timestamppb.New(p.keywordSync.since)}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check keyword index: %w", err)
+	}
+	if total == uint64(p.keywordIndex.Len()) && recent == uint64(len(p.keywordSync.written)) {
+		return nil
+	}
+
+	slog.Info("Reloading keyword index changed by another process", "points", total, "indexed", p.keywordIndex.Len())
+	return p.loadKeywordIndex(ctx)
+}
+
+// keywordIndexUpsert adds points written by this pipeline to the keyword index
+func (p *Pipeline) keywordIndexUpsert(points []*qdrant.PointStruct) {
+	p.keywordSync.mu.Lock()
+	defer p.keywordSync.mu.Unlock()
+
+	// An index that is not loaded yet picks the points up when it is
+	if !p.keywordSync.loaded {
+		return
+	}
+	for _, point := range points {
+		id := formatPointID(point.GetId())
+		result := newSearchResult(point.GetPayload(), 0)
+		p.keywordIndex.Upsert(id, result, point.GetPayload())
+		p.keywordSync.written[id] = keywordPoint{docID: result.DocID, chunkIndex: result.ChunkIndex}
+	}
+}
+
+// keywordIndexDelete removes the chunks of a document deleted by this pipeline from the keyword
+// index, starting from chunk index fromChunk
+func (p *Pipeline) keywordIndexDelete(docID string, fromChunk int) {
+	p.keywordSync.mu.Lock()
+	defer p.keywordSync.mu.Unlock()
+
+	if !p.keywordSync.loaded {
+		return
+	}
+	p.keywordIndex.DeleteDocument(docID, fromChunk)
+	for id, point := range p.keywordSync.written {
+		if point.docID == docID && point.chunkIndex >= fromChunk {
+			delete(p.keywordSync.written, id)
+		}
+	}
+}
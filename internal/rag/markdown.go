@@ -0,0 +1,281 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+)
+
+// headingPathSeparator joins the headings of a heading path
+const headingPathSeparator = " > "
+
+// atxHeadingPattern matches an ATX heading line such as "## Lifecycle ##"
+var atxHeadingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+// MarkdownChunker splits Markdown documents along their heading hierarchy. Every chunk belongs
+// to a single section and records the path of enclosing headings. Sections longer than the chunk
+// size are split between paragraphs, then lines, then words; fenced code blocks are never split,
+// even if longer than the chunk size. Text without headings is chunked by paragraphs.
+type MarkdownChunker struct {
+	chunkSize int
+}
+
+// NewMarkdownChunker creates a Markdown chunker producing chunks of at most chunkSize bytes,
+// except for oversized code blocks. A non-positive size keeps every section whole.
+func NewMarkdownChunker(chunkSize int) *MarkdownChunker {
+	return &MarkdownChunker{chunkSize: chunkSize}
+}
+
+// ChunkText splits Markdown text into chunks
+func (c *MarkdownChunker) ChunkText(text string) []string {
+	chunks := c.Chunk(text)
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+	return texts
+}
+
+// Chunk splits Markdown text into chunks with their heading paths
+func (c *MarkdownChunker) Chunk(text string) []Chunk {
+	var chunks []Chunk
+	for _, section := range splitMarkdownSections(parseMarkdownBlocks(text)) {
+		for _, chunkText := range c.packBlocks(section.blocks) {
+			chunks = append(chunks, Chunk{Text: chunkText, HeadingPath: section.headingPath})
+		}
+	}
+	return chunks
+}
+
+// markdownBlockKind distinguishes the blocks a Markdown document is split into
+type markdownBlockKind int
+
+const (
+	markdownParagraph markdownBlockKind = iota
+	markdownHeading
+	markdownCode
+)
+
+// markdownBlock is a heading line, a fenced code block or a run of non-blank lines
+type markdownBlock struct {
+	kind  markdownBlockKind
+	text  string
+	level int
+	title string
+}
+
+// parseMarkdownBlocks splits Markdown text into headings, fenced code blocks and paragraphs.
+// Lines inside fenced code blocks are never taken for headings.
+func parseMarkdownBlocks(text string) []markdownBlock {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var blocks []markdownBlock
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, markdownBlock{kind: markdownParagraph, text: strings.Join(paragraph, "\n")})
+			paragraph = nil
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+
+		if fence := openingFence(line); fence != "" {
+			flushParagraph()
+			code := []string{line}
+			for i+1 < len(lines) {
+				i++
+				code = append(code, lines[i])
+				if isClosingFence(lines[i], fence) {
+					break
+				}
+			}
+			blocks = append(blocks, markdownBlock{kind: markdownCode, text: strings.Join(code, "\n")})
+			continue
+		}
+
+		if match := atxHeadingPattern.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			blocks = append(blocks, markdownBlock{
+				kind:  markdownHeading,
+				text:  line,
+				level: len(match[1]),
+				title: strings.TrimSpace(match[2]),
+			})
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flushParagraph()
+
+	return blocks
+}
+
+// openingFence returns the fence of a line opening a fenced code block, or "" for other lines
+func openingFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return ""
+	}
+	for _, marker := range []byte{'`', '~'} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == marker {
+			n++
+		}
+		// Backtick fences cannot have backticks in their info string
+		if n >= 3 && (marker == '~' || !strings.Contains(trimmed[n:], "`")) {
+			return trimmed[:n]
+		}
+	}
+	return ""
+}
+
+// isClosingFence reports whether a line closes a code block opened with fence
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 || len(trimmed) < len(fence) {
+		return false
+	}
+	return strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// markdownSection is the content under a heading, up to the next heading
+type markdownSection struct {
+	headingPath string
+	blocks      []markdownBlock
+}
+
+// splitMarkdownSections groups blocks into sections by heading and computes their heading paths.
+// A heading directly followed by another heading is kept with the next section.
+func splitMarkdownSections(blocks []markdownBlock) []markdownSection {
+	type heading struct {
+		level int
+		title string
+	}
+	var stack []heading
+
+	var sections []markdownSection
+	var current markdownSection
+	headingsOnly := true
+	for _, block := range blocks {
+		if block.kind == markdownHeading {
+			for len(stack) > 0 && stack[len(stack)-1].level >= block.level {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, heading{level: block.level, title: block.title})
+
+			titles := make([]string, 0, len(stack))
+			for _, h := range stack {
+				if h.title != "" {
+					titles = append(titles, h.title)
+				}
+			}
+
+			if headingsOnly {
+				// Carry headings without content over into the new section
+				current.headingPath = strings.Join(titles, headingPathSeparator)
+				current.blocks = append(current.blocks, block)
+				continue
+			}
+			sections = append(sections, current)
+			current = markdownSection{
+				headingPath: strings.Join(titles, headingPathSeparator),
+				blocks:      []markdownBlock{block},
+			}
+			headingsOnly = true
+			continue
+		}
+
+		current.blocks = append(current.blocks, block)
+		headingsOnly = false
+	}
+	if len(current.blocks) > 0 {
+		sections = append(sections, current)
+	}
+
+	return sections
+}
+
+// packBlocks joins the blocks of a section into chunks of at most chunkSize bytes
+func (c *MarkdownChunker) packBlocks(blocks []markdownBlock) []string {
+	var chunks []string
+	var current []string
+	currentSize := 0
+
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, strings.Join(current, "\n\n"))
+			current = nil
+			currentSize = 0
+		}
+	}
+	add := func(text string) {
+		if len(current) > 0 && c.chunkSize > 0 && currentSize+2+len(text) > c.chunkSize {
+			flush()
+		}
+		if len(current) > 0 {
+			currentSize += 2
+		}
+		current = append(current, text)
+		currentSize += len(text)
+	}
+
+	for _, block := range blocks {
+		if c.chunkSize <= 0 || len(block.text) <= c.chunkSize || block.kind == markdownCode {
+			add(block.text)
+			continue
+		}
+
+		// An oversized paragraph is split on its own
+		flush()
+		for _, piece := range splitOversized(block.text, c.chunkSize) {
+			add(piece)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitOversized splits text into pieces of at most size bytes between lines,
+// falling back to words for lines longer than size
+func splitOversized(text string, size int) []string {
+	var pieces []string
+	var current strings.Builder
+
+	appendPart := func(part, separator string) {
+		if current.Len() > 0 && current.Len()+len(separator)+len(part) > size {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString(separator)
+		}
+		current.WriteString(part)
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) <= size {
+			appendPart(line, "\n")
+			continue
+		}
+		for i, word := range strings.Fields(line) {
+			separator := " "
+			if i == 0 {
+				separator = "\n"
+			}
+			appendPart(word, separator)
+		}
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+
+	return pieces
+}
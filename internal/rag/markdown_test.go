@@ -0,0 +1,189 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestMarkdownChunker_Chunk(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunkSize int
+		text      string
+		want      []Chunk
+	}{
+		{
+			name:      "empty text",
+			chunkSize: 100,
+			text:      "",
+			want:      nil,
+		},
+		{
+			name:      "plain text is chunked by paragraphs",
+			chunkSize: 30,
+			text:      "First paragraph here.\n\nSecond paragraph.\n\nThird one.",
+			want: []Chunk{
+				{Text: "First paragraph here."},
+				{Text: "Second paragraph.\n\nThird one."},
+			},
+		},
+		{
+			name:      "sections record heading paths",
+			chunkSize: 1000,
+			text: `Intro text.
+
+# Pods
+
+A Pod is the smallest unit.
+
+## Lifecycle
+
+Pods are ephemeral.
+
+### Phases ###
+
+Pending, Running.
+
+## Networking
+
+Each Pod gets an IP.
+
+# Services
+
+Stable endpoints.`,
+			want: []Chunk{
+				{Text: "Intro text."},
+				{Text: "# Pods\n\nA Pod is the smallest unit.", HeadingPath: "Pods"},
+				{Text: "## Lifecycle\n\nPods are ephemeral.", HeadingPath: "Pods > Lifecycle"},
+				{Text: "### Phases ###\n\nPending, Running.", HeadingPath: "Pods > Lifecycle > Phases"},
+				{Text: "## Networking\n\nEach Pod gets an IP.", HeadingPath: "Pods > Networking"},
+				{Text: "# Services\n\nStable endpoints.", HeadingPath: "Services"},
+			},
+		},
+		{
+			name:      "heading without content stays with the next section",
+			chunkSize: 1000,
+			text:      "# Kubernetes\n## Pods\nA Pod runs containers.",
+			want: []Chunk{
+				{Text: "# Kubernetes\n\n## Pods\n\nA Pod runs containers.", HeadingPath: "Kubernetes > Pods"},
+			},
+		},
+		{
+			name:      "headings inside code blocks are ignored",
+			chunkSize: 1000,
+			text:      "# Config\n\n```bash\n# not a heading\nexport A=1\n```\n\nDone.",
+			want: []Chunk{
+				{Text: "# Config\n\n```bash\n# not a heading\nexport A=1\n```\n\nDone.", HeadingPath: "Config"},
+			},
+		},
+		{
+			name:      "code blocks are never split and keep indentation",
+			chunkSize: 40,
+			text:      "# Manifest\n\nApply this:\n\n~~~yaml\napiVersion: v1\nkind: Pod\nmetadata:\n  name: nginx\n\nspec:\n  containers: []\n~~~\n\nThen check.",
+			want: []Chunk{
+				{Text: "# Manifest\n\nApply this:", HeadingPath: "Manifest"},
+				{Text: "~~~yaml\napiVersion: v1\nkind: Pod\nmetadata:\n  name: nginx\n\nspec:\n  containers: []\n~~~", HeadingPath: "Manifest"},
+				{Text: "Then check.", HeadingPath: "Manifest"},
+			},
+		},
+		{
+			name:      "unclosed code block runs to the end",
+			chunkSize: 1000,
+			text:      "```go\nfunc main() {}\n\n## not a heading",
+			want: []Chunk{
+				{Text: "```go\nfunc main() {}\n\n## not a heading"},
+			},
+		},
+		{
+			name:      "oversized paragraph is split by lines then words",
+			chunkSize: 20,
+			text:      "## Flags\nshort line\nthis line is much longer than twenty",
+			want: []Chunk{
+				{Text: "## Flags", HeadingPath: "Flags"},
+				{Text: "short line\nthis line", HeadingPath: "Flags"},
+				{Text: "is much longer than", HeadingPath: "Flags"},
+				{Text: "twenty", HeadingPath: "Flags"},
+			},
+		},
+		{
+			name:      "hash without space is not a heading",
+			chunkSize: 1000,
+			text:      "#hashtag\n\n#5 issue",
+			want: []Chunk{
+				{Text: "#hashtag\n\n#5 issue"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMarkdownChunker(tt.chunkSize).Chunk(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunk() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownChunker_ChunkText(t *testing.T) {
+	text := "# Pods\n\nA Pod is the smallest unit.\n\n## Lifecycle\n\nPods are ephemeral."
+
+	got := NewMarkdownChunker(1000).ChunkText(text)
+	want := []string{"# Pods\n\nA Pod is the smallest unit.", "## Lifecycle\n\nPods are ephemeral."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChunkText() = %q, want %q", got, want)
+	}
+
+	for _, chunk := range NewMarkdownChunker(10).ChunkText(strings.Repeat("word ", 50)) {
+		if len(chunk) > 10 {
+			t.Errorf("ChunkText() chunk %q is longer than the chunk size", chunk)
+		}
+	}
+}
+
+func TestPipeline_MarkdownHeadingPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, texts []string) ([][]float32, error) {
+			embeddings := make([][]float32, len(texts))
+			for i := range texts {
+				embeddings[i] = []float32{1, float32(i)}
+			}
+			return embeddings, nil
+		},
+	)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "phases").Return([]float32{1, 1}, nil)
+
+	ctx := context.Background()
+	pipeline, err := NewPipeline(NewMarkdownChunker(1000), mockLLM, newTestMemoryStore(t, ""), 2, 1)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	if err := pipeline.Ingest(ctx, "# Pods\n\nSmallest unit.\n\n## Lifecycle\n\nPending, Running.", "pods", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	results, err := pipeline.Retrieve(ctx, "phases", RetrieveOptions{})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].HeadingPath != "Pods > Lifecycle" {
+		t.Fatalf("Retrieve() = %+v, want the Lifecycle section", results)
+	}
+	if contextText := BuildContext(results); !strings.Contains(contextText, "Section: Pods > Lifecycle") {
+		t.Errorf("BuildContext() = %q, want the heading path", contextText)
+	}
+
+	if err := pipeline.Ingest(ctx, "text", "other", map[string]any{"heading_path": "x"}); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("Ingest() with heading_path metadata error = %v, want ErrInvalidMetadata", err)
+	}
+}
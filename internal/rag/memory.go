@@ -0,0 +1,341 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// MemoryStore is an in-process VectorDatabase using brute-force cosine similarity.
+// It is meant for development and tests; if a path is set, the points are persisted
+// to that file after every change and loaded back on startup.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	path       string
+	vectorSize uint64
+	points     map[string]*memoryPoint
+}
+
+// memoryPoint is a stored point with its vector pre-normalized for cosine similarity
+type memoryPoint struct {
+	point      *qdrant.PointStruct
+	normalized []float32
+}
+
+// memoryStoreFile is the on-disk representation of a MemoryStore
+type memoryStoreFile struct {
+	VectorSize uint64            `json:"vector_size"`
+	Points     []json.RawMessage `json:"points"`
+}
+
+// NewMemoryStore creates an in-memory vector store, loading existing points from path if it is set
+func NewMemoryStore(path string) (*MemoryStore, error) {
+	ms := &MemoryStore{
+		path:   path,
+		points: make(map[string]*memoryPoint),
+	}
+
+	if path != "" {
+		if err := ms.load(); err != nil {
+			return nil, fmt.Errorf("failed to load memory store: %w", err)
+		}
+	}
+
+	return ms, nil
+}
+
+// EnsureCollection sets the vector size, validating it against previously stored points
+func (ms *MemoryStore) EnsureCollection(ctx context.Context, vectorSize uint64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.vectorSize != 0 && ms.vectorSize != vectorSize {
+		return fmt.Errorf("%w: memory store holds %d-dimensional vectors, but the embedding model produces %d",
+			ErrVectorSizeMismatch, ms.vectorSize, vectorSize)
+	}
+	ms.vectorSize = vectorSize
+	return nil
+}
+
+// UpsertPoints inserts or replaces points by ID
+func (ms *MemoryStore) UpsertPoints(ctx context.Context, pointsToUpsert []*qdrant.PointStruct) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, point := range pointsToUpsert {
+		vector := point.GetVectors().GetVector().GetDense().GetData()
+		if ms.vectorSize != 0 && uint64(len(vector)) != ms.vectorSize {
+			return fmt.Errorf("failed to upsert points: vector size %d does not match collection size %d", len(vector), ms.vectorSize)
+		}
+		ms.points[formatPointID(point.GetId())] = &memoryPoint{
+			point:      point,
+			normalized: normalize(vector),
+		}
+	}
+
+	return ms.save()
+}
+
+// DeletePoints deletes all points matching the filter
+func (ms *MemoryStore) DeletePoints(ctx context.Context, filter *qdrant.Filter) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for id, mp := range ms.points {
+		ok, err := matchFilter(filter, mp.point.GetPayload())
+		if err != nil {
+			return fmt.Errorf("failed to delete points: %w", err)
+		}
+		if ok {
+			delete(ms.points, id)
+		}
+	}
+
+	return ms.save()
+}
+
+// ScrollPoints returns points matching the filter ordered by ID, starting at offset
+func (ms *MemoryStore) ScrollPoints(ctx context.Context, filter *qdrant.Filter, limit uint32, offset *qdrant.PointId) ([]*qdrant.RetrievedPoint, *qdrant.PointId, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	matched, err := ms.filterPoints(filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scroll points: %w", err)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return lessPointID(matched[i].point.GetId(), matched[j].point.GetId())
+	})
+
+	start := 0
+	if offset != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return !lessPointID(matched[i].point.GetId(), offset)
+		})
+	}
+
+	end := min(start+int(limit), len(matched))
+	page := make([]*qdrant.RetrievedPoint, 0, end-start)
+	for _, mp := range matched[start:end] {
+		page = append(page, &qdrant.RetrievedPoint{
+			Id:      mp.point.GetId(),
+			Payload: mp.point.GetPayload(),
+		})
+	}
+
+	var nextOffset *qdrant.PointId
+	if end < len(matched) {
+		nextOffset = matched[end].point.GetId()
+	}
+
+	return page, nextOffset, nil
+}
+
+// CountPoints counts points matching the filter
+func (ms *MemoryStore) CountPoints(ctx context.Context, filter *qdrant.Filter) (uint64, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	matched, err := ms.filterPoints(filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	return uint64(len(matched)), nil
+}
+
+// GetVectors returns the stored vectors of the points with the given IDs, keyed by point ID
+func (ms *MemoryStore) GetVectors(ctx context.Context, ids []*qdrant.PointId) (map[string][]float32, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	vectors := make(map[string][]float32, len(ids))
+	for _, id := range ids {
+		if mp, ok := ms.points[formatPointID(id)]; ok {
+			vectors[formatPointID(id)] = mp.point.GetVectors().GetVector().GetDense().GetData()
+		}
+	}
+	return vectors, nil
+}
+
+// Search returns the points most similar to the vector by cosine similarity
+func (ms *MemoryStore) Search(ctx context.Context, vector []float32, limit uint64, opts SearchOptions) ([]SearchResult, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	query := normalize(vector)
+
+	results := make([]SearchResult, 0, len(ms.points))
+	for _, mp := range ms.points {
+		payload := mp.point.GetPayload()
+		text := payload["text"].GetStringValue()
+		if text == "" {
+			continue
+		}
+		ok, err := matchFilter(opts.Filter, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		score := dot(query, mp.normalized)
+		if opts.ScoreThreshold > 0 && score < opts.ScoreThreshold {
+			continue
+		}
+
+		result := newSearchResult(payload, score)
+		if opts.WithVectors {
+			result.Vector = mp.point.GetVectors().GetVector().GetDense().GetData()
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if uint64(len(results)) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// filterPoints returns all stored points matching the filter
+func (ms *MemoryStore) filterPoints(filter *qdrant.Filter) ([]*memoryPoint, error) {
+	matched := make([]*memoryPoint, 0, len(ms.points))
+	for _, mp := range ms.points {
+		ok, err := matchFilter(filter, mp.point.GetPayload())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, mp)
+		}
+	}
+	return matched, nil
+}
+
+// load reads points from the store file; a missing file means an empty store
+func (ms *MemoryStore) load() error {
+	data, err := os.ReadFile(ms.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file memoryStoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", ms.path, err)
+	}
+
+	ms.vectorSize = file.VectorSize
+	for _, raw := range file.Points {
+		point := &qdrant.PointStruct{}
+		if err := protojson.Unmarshal(raw, point); err != nil {
+			return fmt.Errorf("failed to decode point in %s: %w", ms.path, err)
+		}
+		ms.points[formatPointID(point.GetId())] = &memoryPoint{
+			point:      point,
+			normalized: normalize(point.GetVectors().GetVector().GetDense().GetData()),
+		}
+	}
+
+	return nil
+}
+
+// save writes all points to the store file atomically; it is a no-op without a path
+func (ms *MemoryStore) save() error {
+	if ms.path == "" {
+		return nil
+	}
+
+	file := memoryStoreFile{
+		VectorSize: ms.vectorSize,
+		Points:     make([]json.RawMessage, 0, len(ms.points)),
+	}
+	for _, mp := range ms.points {
+		raw, err := protojson.Marshal(mp.point)
+		if err != nil {
+			return fmt.Errorf("failed to encode point: %w", err)
+		}
+		file.Points = append(file.Points, raw)
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode memory store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(ms.path), filepath.Base(ms.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to persist memory store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to persist memory store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to persist memory store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), ms.path); err != nil {
+		return fmt.Errorf("failed to persist memory store: %w", err)
+	}
+
+	return nil
+}
+
+// lessPointID orders point IDs the way Qdrant does: numeric IDs before UUIDs
+func lessPointID(a, b *qdrant.PointId) bool {
+	aUUID, bUUID := a.GetUuid(), b.GetUuid()
+	switch {
+	case aUUID == "" && bUUID == "":
+		return a.GetNum() < b.GetNum()
+	case aUUID == "" || bUUID == "":
+		return aUUID == ""
+	default:
+		return aUUID < bUUID
+	}
+}
+
+// normalize returns the vector scaled to unit length
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	normalized := make([]float32, len(vector))
+	if norm == 0 {
+		return normalized
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized
+}
+
+// dot returns the dot product of two vectors of equal length
+func dot(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return float32(sum)
+}
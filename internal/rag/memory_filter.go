@@ -0,0 +1,188 @@
+package rag
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// matchFilter evaluates a Qdrant filter against a point payload.
+// It supports the subset of conditions used by this service: nested filters,
+// keyword/integer/boolean matches, numeric and datetime ranges, and is-empty checks.
+func matchFilter(filter *qdrant.Filter, payload map[string]*qdrant.Value) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+
+	for _, cond := range filter.GetMust() {
+		ok, err := matchCondition(cond, payload)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	for _, cond := range filter.GetMustNot() {
+		ok, err := matchCondition(cond, payload)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+
+	if len(filter.GetShould()) > 0 {
+		for _, cond := range filter.GetShould() {
+			ok, err := matchCondition(cond, payload)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// matchCondition evaluates a single filter condition against a point payload
+func matchCondition(cond *qdrant.Condition, payload map[string]*qdrant.Value) (bool, error) {
+	switch c := cond.GetConditionOneOf().(type) {
+	case *qdrant.Condition_Filter:
+		return matchFilter(c.Filter, payload)
+	case *qdrant.Condition_IsEmpty:
+		return len(payloadValues(payload, c.IsEmpty.GetKey())) == 0, nil
+	case *qdrant.Condition_Field:
+		return matchFieldCondition(c.Field, payloadValues(payload, c.Field.GetKey()))
+	default:
+		return false, fmt.Errorf("unsupported filter condition %T", c)
+	}
+}
+
+// matchFieldCondition checks whether any of the field's values satisfies the condition
+func matchFieldCondition(field *qdrant.FieldCondition, values []*qdrant.Value) (bool, error) {
+	switch {
+	case field.GetMatch() != nil:
+		return matchValues(field.GetMatch(), values)
+	case field.GetRange() != nil:
+		r := field.GetRange()
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			n, ok := numericValue(v)
+			return ok && inRange(n, r.Lt, r.Gt, r.Gte, r.Lte)
+		}), nil
+	case field.GetDatetimeRange() != nil:
+		r := field.GetDatetimeRange()
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			t, err := time.Parse(time.RFC3339, v.GetStringValue())
+			if err != nil {
+				return false
+			}
+			return (r.Lt == nil || t.Before(r.Lt.AsTime())) &&
+				(r.Gt == nil || t.After(r.Gt.AsTime())) &&
+				(r.Gte == nil || !t.Before(r.Gte.AsTime())) &&
+				(r.Lte == nil || !t.After(r.Lte.AsTime()))
+		}), nil
+	case field.IsEmpty != nil:
+		return (len(values) == 0) == field.GetIsEmpty(), nil
+	default:
+		return false, fmt.Errorf("unsupported condition on field %q", field.GetKey())
+	}
+}
+
+// matchValues checks whether any of the values satisfies a match condition
+func matchValues(match *qdrant.Match, values []*qdrant.Value) (bool, error) {
+	switch m := match.GetMatchValue().(type) {
+	case *qdrant.Match_Keyword:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			return isString(v) && v.GetStringValue() == m.Keyword
+		}), nil
+	case *qdrant.Match_Integer:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			return isInteger(v) && v.GetIntegerValue() == m.Integer
+		}), nil
+	case *qdrant.Match_Boolean:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			_, ok := v.GetKind().(*qdrant.Value_BoolValue)
+			return ok && v.GetBoolValue() == m.Boolean
+		}), nil
+	case *qdrant.Match_Keywords:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			return isString(v) && slices.Contains(m.Keywords.GetStrings(), v.GetStringValue())
+		}), nil
+	case *qdrant.Match_Integers:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			return isInteger(v) && slices.Contains(m.Integers.GetIntegers(), v.GetIntegerValue())
+		}), nil
+	case *qdrant.Match_ExceptKeywords:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			return !isString(v) || !slices.Contains(m.ExceptKeywords.GetStrings(), v.GetStringValue())
+		}), nil
+	case *qdrant.Match_ExceptIntegers:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			return !isInteger(v) || !slices.Contains(m.ExceptIntegers.GetIntegers(), v.GetIntegerValue())
+		}), nil
+	case *qdrant.Match_Text:
+		return slices.ContainsFunc(values, func(v *qdrant.Value) bool {
+			return isString(v) && strings.Contains(v.GetStringValue(), m.Text)
+		}), nil
+	default:
+		return false, fmt.Errorf("unsupported match condition %T", m)
+	}
+}
+
+// payloadValues resolves a dotted key ("metadata.team") in the payload,
+// flattening list values so that conditions match any element
+func payloadValues(payload map[string]*qdrant.Value, key string) []*qdrant.Value {
+	parts := strings.Split(key, ".")
+
+	value, ok := payload[parts[0]]
+	if !ok {
+		return nil
+	}
+	for _, part := range parts[1:] {
+		value, ok = value.GetStructValue().GetFields()[part]
+		if !ok {
+			return nil
+		}
+	}
+
+	if list, ok := value.GetKind().(*qdrant.Value_ListValue); ok {
+		return list.ListValue.GetValues()
+	}
+	if _, ok := value.GetKind().(*qdrant.Value_NullValue); ok {
+		return nil
+	}
+	return []*qdrant.Value{value}
+}
+
+// numericValue returns the numeric value of an integer or double payload value
+func numericValue(v *qdrant.Value) (float64, bool) {
+	switch k := v.GetKind().(type) {
+	case *qdrant.Value_IntegerValue:
+		return float64(k.IntegerValue), true
+	case *qdrant.Value_DoubleValue:
+		return k.DoubleValue, true
+	default:
+		return 0, false
+	}
+}
+
+// inRange checks a number against optional range bounds
+func inRange(n float64, lt, gt, gte, lte *float64) bool {
+	return (lt == nil || n < *lt) &&
+		(gt == nil || n > *gt) &&
+		(gte == nil || n >= *gte) &&
+		(lte == nil || n <= *lte)
+}
+
+func isString(v *qdrant.Value) bool {
+	_, ok := v.GetKind().(*qdrant.Value_StringValue)
+	return ok
+}
+
+func isInteger(v *qdrant.Value) bool {
+	_, ok := v.GetKind().(*qdrant.Value_IntegerValue)
+	return ok
+}
@@ -0,0 +1,357 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newMemoryPoint(docID string, chunkIndex int64, text string, vector []float32) *qdrant.PointStruct {
+	return &qdrant.PointStruct{
+		Id:      qdrant.NewID(chunkPointID(docID, int(chunkIndex))),
+		Vectors: qdrant.NewVectors(vector...),
+		Payload: map[string]*qdrant.Value{
+			"text":        qdrant.NewValueString(text),
+			"doc_id":      qdrant.NewValueString(docID),
+			"chunk_index": qdrant.NewValueInt(chunkIndex),
+		},
+	}
+}
+
+func newTestMemoryStore(t *testing.T, path string) *MemoryStore {
+	t.Helper()
+
+	ms, err := NewMemoryStore(path)
+	if err != nil {
+		t.Fatalf("NewMemoryStore() unexpected error: %v", err)
+	}
+	if err := ms.EnsureCollection(context.Background(), 2); err != nil {
+		t.Fatalf("EnsureCollection() unexpected error: %v", err)
+	}
+	return ms
+}
+
+func mustTimestamp(t *testing.T, value string) *timestamppb.Timestamp {
+	t.Helper()
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("time.Parse(%q) unexpected error: %v", value, err)
+	}
+	return timestamppb.New(parsed)
+}
+
+func TestMemoryStore_Search(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestMemoryStore(t, "")
+
+	err := ms.UpsertPoints(ctx, []*qdrant.PointStruct{
+		newMemoryPoint("doc-a", 0, "east", []float32{1, 0}),
+		newMemoryPoint("doc-a", 1, "north-east", []float32{1, 1}),
+		newMemoryPoint("doc-b", 0, "north", []float32{0, 3}),
+	})
+	if err != nil {
+		t.Fatalf("UpsertPoints() unexpected error: %v", err)
+	}
+
+	results, err := ms.Search(ctx, []float32{2, 0}, 2, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Search() returned %d results, want 2", len(results))
+	}
+	if results[0].Text != "east" || results[1].Text != "north-east" {
+		t.Errorf("Search() order = [%s %s], want [east north-east]", results[0].Text, results[1].Text)
+	}
+	if results[0].Score < 0.999 {
+		t.Errorf("Search() top score = %f, want 1", results[0].Score)
+	}
+	if results[1].DocID != "doc-a" || results[1].ChunkIndex != 1 {
+		t.Errorf("Search() result = %+v, want doc-a chunk 1", results[1])
+	}
+
+	results, err = ms.Search(ctx, []float32{2, 0}, 3, SearchOptions{ScoreThreshold: 0.9})
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Text != "east" {
+		t.Errorf("Search() with threshold = %+v, want only the east chunk", results)
+	}
+}
+
+func TestMemoryStore_UpsertPoints_VectorSize(t *testing.T) {
+	ms := newTestMemoryStore(t, "")
+
+	err := ms.UpsertPoints(context.Background(), []*qdrant.PointStruct{
+		newMemoryPoint("doc", 0, "text", []float32{1, 2, 3}),
+	})
+	if err == nil {
+		t.Fatal("UpsertPoints() expected error for wrong vector size but got nil")
+	}
+}
+
+func TestMemoryStore_DeletePoints(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestMemoryStore(t, "")
+
+	err := ms.UpsertPoints(ctx, []*qdrant.PointStruct{
+		newMemoryPoint("doc-a", 0, "a0", []float32{1, 0}),
+		newMemoryPoint("doc-a", 1, "a1", []float32{1, 0}),
+		newMemoryPoint("doc-a", 2, "a2", []float32{1, 0}),
+		newMemoryPoint("doc-b", 0, "b0", []float32{1, 0}),
+	})
+	if err != nil {
+		t.Fatalf("UpsertPoints() unexpected error: %v", err)
+	}
+
+	gte := float64(1)
+	err = ms.DeletePoints(ctx, &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatchKeyword("doc_id", "doc-a"),
+			qdrant.NewRange("chunk_index", &qdrant.Range{Gte: &gte}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("DeletePoints() unexpected error: %v", err)
+	}
+
+	count, err := ms.CountPoints(ctx, documentFilter("doc-a"))
+	if err != nil {
+		t.Fatalf("CountPoints() unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("CountPoints(doc-a) = %d, want 1", count)
+	}
+
+	count, err = ms.CountPoints(ctx, nil)
+	if err != nil {
+		t.Fatalf("CountPoints() unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("CountPoints() = %d, want 2", count)
+	}
+}
+
+func TestMemoryStore_ScrollPoints(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestMemoryStore(t, "")
+
+	points := make([]*qdrant.PointStruct, 0, 5)
+	for i := range 5 {
+		points = append(points, newMemoryPoint("doc", int64(i), "chunk", []float32{1, 0}))
+	}
+	if err := ms.UpsertPoints(ctx, points); err != nil {
+		t.Fatalf("UpsertPoints() unexpected error: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	var offset *qdrant.PointId
+	pages := 0
+	for {
+		page, next, err := ms.ScrollPoints(ctx, documentFilter("doc"), 2, offset)
+		if err != nil {
+			t.Fatalf("ScrollPoints() unexpected error: %v", err)
+		}
+		pages++
+		for _, point := range page {
+			id := formatPointID(point.GetId())
+			if seen[id] {
+				t.Errorf("ScrollPoints() returned point %s twice", id)
+			}
+			seen[id] = true
+		}
+		if next == nil {
+			break
+		}
+		offset = next
+	}
+
+	if len(seen) != 5 {
+		t.Errorf("ScrollPoints() returned %d points, want 5", len(seen))
+	}
+	if pages != 3 {
+		t.Errorf("ScrollPoints() returned %d pages, want 3", pages)
+	}
+}
+
+func TestMemoryStore_Persistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vectors.json")
+
+	ms := newTestMemoryStore(t, path)
+	err := ms.UpsertPoints(ctx, []*qdrant.PointStruct{
+		newMemoryPoint("doc", 0, "persisted", []float32{0, 1}),
+	})
+	if err != nil {
+		t.Fatalf("UpsertPoints() unexpected error: %v", err)
+	}
+
+	reloaded := newTestMemoryStore(t, path)
+	results, err := reloaded.Search(ctx, []float32{0, 1}, 1, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Text != "persisted" || results[0].DocID != "doc" {
+		t.Errorf("Search() after reload = %+v, want the persisted point", results)
+	}
+
+	err = reloaded.EnsureCollection(ctx, 3)
+	if !errors.Is(err, ErrVectorSizeMismatch) {
+		t.Errorf("EnsureCollection() error = %v, want ErrVectorSizeMismatch", err)
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	payload := map[string]*qdrant.Value{
+		"doc_id":      qdrant.NewValueString("doc-1"),
+		"chunk_index": qdrant.NewValueInt(3),
+		"ingested_at": qdrant.NewValueString("2025-01-15T10:00:00Z"),
+		"tags":        qdrant.NewValueFromList(qdrant.NewValueString("go"), qdrant.NewValueString("rag")),
+		"metadata": qdrant.NewValueFromFields(map[string]*qdrant.Value{
+			"team": qdrant.NewValueString("search"),
+		}),
+	}
+	one, five := float64(1), float64(5)
+
+	tests := []struct {
+		name    string
+		filter  *qdrant.Filter
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "nil filter matches",
+			want: true,
+		},
+		{
+			name:   "keyword match",
+			filter: documentFilter("doc-1"),
+			want:   true,
+		},
+		{
+			name:   "keyword mismatch",
+			filter: documentFilter("doc-2"),
+			want:   false,
+		},
+		{
+			name:   "must not",
+			filter: &qdrant.Filter{MustNot: []*qdrant.Condition{qdrant.NewMatchKeyword("doc_id", "doc-1")}},
+			want:   false,
+		},
+		{
+			name: "should matches any",
+			filter: &qdrant.Filter{Should: []*qdrant.Condition{
+				qdrant.NewMatchKeyword("doc_id", "doc-2"),
+				qdrant.NewMatchInt("chunk_index", 3),
+			}},
+			want: true,
+		},
+		{
+			name:   "range",
+			filter: &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewRange("chunk_index", &qdrant.Range{Gte: &one, Lt: &five})}},
+			want:   true,
+		},
+		{
+			name:   "list value matches any element",
+			filter: &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewMatchKeywords("tags", "python", "rag")}},
+			want:   true,
+		},
+		{
+			name:   "nested key",
+			filter: &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewMatchKeyword("metadata.team", "search")}},
+			want:   true,
+		},
+		{
+			name:   "missing field is empty",
+			filter: &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewIsEmpty("source")}},
+			want:   true,
+		},
+		{
+			name: "datetime range",
+			filter: &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewDatetimeRange("ingested_at", &qdrant.DatetimeRange{
+				Gte: mustTimestamp(t, "2025-01-01T00:00:00Z"),
+			})}},
+			want: true,
+		},
+		{
+			name:    "unsupported condition",
+			filter:  &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewHasID(qdrant.NewIDNum(1))}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchFilter(tt.filter, payload)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("matchFilter() expected error but got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("matchFilter() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("matchFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_MemoryStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, texts []string) ([][]float32, error) {
+			embeddings := make([][]float32, len(texts))
+			for i := range texts {
+				embeddings[i] = []float32{1, float32(i)}
+			}
+			return embeddings, nil
+		},
+	).Times(2)
+
+	ctx := context.Background()
+	ms := newTestMemoryStore(t, "")
+	pipeline, err := NewPipeline(NewChunker(20, 0), mockLLM, ms, 2, 3)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	if err := pipeline.Ingest(ctx, "one two three four five six seven eight nine ten eleven twelve", "doc", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+	before, err := pipeline.GetDocument(ctx, "doc")
+	if err != nil {
+		t.Fatalf("GetDocument() unexpected error: %v", err)
+	}
+
+	if err := pipeline.Ingest(ctx, "one two three", "doc", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+	after, err := pipeline.GetDocument(ctx, "doc")
+	if err != nil {
+		t.Fatalf("GetDocument() unexpected error: %v", err)
+	}
+
+	if before.ChunkCount <= after.ChunkCount {
+		t.Fatalf("re-ingest chunk count = %d, want fewer than %d", after.ChunkCount, before.ChunkCount)
+	}
+	if after.ChunkCount != 1 || !strings.Contains(after.Chunks[0].Text, "three") {
+		t.Errorf("GetDocument() after re-ingest = %+v, want a single chunk", after)
+	}
+}
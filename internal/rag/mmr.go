@@ -0,0 +1,79 @@
+package rag
+
+import "math"
+
+// selectMMR picks up to limit candidates by Maximal Marginal Relevance: each step takes the
+// candidate maximising lambda*relevance - (1-lambda)*(max similarity to the already selected ones).
+// Relevance is the candidate score normalised to [0, 1], so it works for cosine, fusion
+// and reranker scores alike. Similarity is the cosine of the candidate vectors; candidates
+// without a vector are never considered redundant.
+func selectMMR(candidates []SearchResult, lambda float64, limit int) []SearchResult {
+	if limit <= 0 || len(candidates) == 0 {
+		return nil
+	}
+
+	relevance := normalizeScores(candidates)
+	vectors := make([][]float32, len(candidates))
+	for i, candidate := range candidates {
+		if len(candidate.Vector) > 0 {
+			vectors[i] = normalize(candidate.Vector)
+		}
+	}
+
+	// maxSimilarity[i] is the highest similarity of candidate i to any selected candidate
+	maxSimilarity := make([]float64, len(candidates))
+	selected := make([]bool, len(candidates))
+	results := make([]SearchResult, 0, min(limit, len(candidates)))
+
+	for len(results) < limit && len(results) < len(candidates) {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range candidates {
+			if selected[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*maxSimilarity[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		selected[best] = true
+		results = append(results, candidates[best])
+
+		if vectors[best] == nil {
+			continue
+		}
+		for i := range candidates {
+			if selected[i] || vectors[i] == nil {
+				continue
+			}
+			maxSimilarity[i] = max(maxSimilarity[i], float64(dot(vectors[i], vectors[best])))
+		}
+	}
+
+	return results
+}
+
+// normalizeScores maps candidate scores to [0, 1]. Non-negative scores (cosine, fusion, most
+// reranker scores) are divided by the maximum so that small score gaps stay small; scores with
+// negative values are min-max normalised. Equal scores all map to 1.
+func normalizeScores(candidates []SearchResult) []float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, candidate := range candidates {
+		lo = min(lo, float64(candidate.Score))
+		hi = max(hi, float64(candidate.Score))
+	}
+	if lo >= 0 {
+		lo = 0
+	}
+
+	normalized := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		if hi == lo {
+			normalized[i] = 1
+			continue
+		}
+		normalized[i] = (float64(candidate.Score) - lo) / (hi - lo)
+	}
+	return normalized
+}
@@ -0,0 +1,90 @@
+package rag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSelectMMR(t *testing.T) {
+	// Two near-identical overlapping chunks and a distinct, slightly less relevant one
+	candidates := []SearchResult{
+		{DocID: "a", ChunkIndex: 0, Score: 0.92, Vector: []float32{1, 0.05, 0}},
+		{DocID: "a", ChunkIndex: 1, Score: 0.91, Vector: []float32{1, 0.06, 0}},
+		{DocID: "b", ChunkIndex: 0, Score: 0.85, Vector: []float32{0.3, 0, 1}},
+	}
+
+	tests := []struct {
+		name   string
+		lambda float64
+		limit  int
+		want   []string
+	}{
+		{
+			name:   "diversity skips the near duplicate",
+			lambda: 0.5,
+			limit:  2,
+			want:   []string{"a#0", "b#0"},
+		},
+		{
+			name:   "pure relevance keeps score order",
+			lambda: 1,
+			limit:  2,
+			want:   []string{"a#0", "a#1"},
+		},
+		{
+			name:   "limit above candidate count",
+			lambda: 0.5,
+			limit:  5,
+			want:   []string{"a#0", "b#0", "a#1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := selectMMR(candidates, tt.lambda, tt.limit)
+
+			if len(results) != len(tt.want) {
+				t.Fatalf("selectMMR() returned %d results, want %d", len(results), len(tt.want))
+			}
+			for i, result := range results {
+				if got := resultKey(result); got != tt.want[i] {
+					t.Errorf("selectMMR()[%d] = %s, want %s", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_Retrieve_MMR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockDB := NewMockVectorDatabase(ctrl)
+
+	candidates := []SearchResult{
+		{Text: "chunk 0", DocID: "a", ChunkIndex: 0, Score: 0.92, Vector: []float32{1, 0}},
+		{Text: "chunk 1", DocID: "a", ChunkIndex: 1, Score: 0.91, Vector: []float32{1, 0.01}},
+		{Text: "other", DocID: "b", ChunkIndex: 0, Score: 0.80, Vector: []float32{0, 1}},
+	}
+
+	mockDB.EXPECT().EnsureCollection(gomock.Any(), uint64(2)).Return(nil)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "query").Return([]float32{1, 0}, nil)
+	mockDB.EXPECT().Search(gomock.Any(), []float32{1, 0}, uint64(10), SearchOptions{WithVectors: true}).Return(candidates, nil)
+
+	pipeline, err := NewPipeline(NewChunker(100, 0), mockLLM, mockDB, 2, 2, WithMMR(0.5, 10))
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	results, err := pipeline.Retrieve(context.Background(), "query", RetrieveOptions{})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+
+	if len(results) != 2 || results[0].DocID != "a" || results[1].DocID != "b" {
+		t.Errorf("Retrieve() = %+v, want chunk a#0 and b#0", results)
+	}
+}
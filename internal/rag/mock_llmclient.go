@@ -8,6 +8,7 @@ import (
 	"reflect"
 
 	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
 // MockLLMClient is a mock of LLMClient interface.
@@ -34,18 +35,18 @@ func (m *MockLLMClient) EXPECT() *MockLLMClientMockRecorder {
 }
 
 // GenerateAnswer mocks base method.
-func (m *MockLLMClient) GenerateAnswer(ctx context.Context, contextText, question string) (string, error) {
+func (m *MockLLMClient) GenerateAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage) (*types.Answer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateAnswer", ctx, contextText, question)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GenerateAnswer", ctx, contextText, question, history)
+	ret0, _ := ret[0].(*types.Answer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateAnswer indicates an expected call of GenerateAnswer.
-func (mr *MockLLMClientMockRecorder) GenerateAnswer(ctx, contextText, question interface{}) *gomock.Call {
+func (mr *MockLLMClientMockRecorder) GenerateAnswer(ctx, contextText, question, history interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAnswer", reflect.TypeOf((*MockLLMClient)(nil).GenerateAnswer), ctx, contextText, question)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAnswer", reflect.TypeOf((*MockLLMClient)(nil).GenerateAnswer), ctx, contextText, question, history)
 }
 
 // GenerateEmbedding mocks base method.
@@ -63,3 +64,18 @@ func (mr *MockLLMClientMockRecorder) GenerateEmbedding(ctx, text interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateEmbedding", reflect.TypeOf((*MockLLMClient)(nil).GenerateEmbedding), ctx, text)
 }
 
+// GenerateEmbeddings mocks base method.
+func (m *MockLLMClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateEmbeddings", ctx, texts)
+	ret0, _ := ret[0].([][]float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateEmbeddings indicates an expected call of GenerateEmbeddings.
+func (mr *MockLLMClientMockRecorder) GenerateEmbeddings(ctx, texts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateEmbeddings", reflect.TypeOf((*MockLLMClient)(nil).GenerateEmbeddings), ctx, texts)
+}
+
@@ -34,6 +34,35 @@ func (m *MockVectorDatabase) EXPECT() *MockVectorDatabaseMockRecorder {
 	return m.recorder
 }
 
+// CountPoints mocks base method.
+func (m *MockVectorDatabase) CountPoints(ctx context.Context, filter *qdrant.Filter) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPoints", ctx, filter)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPoints indicates an expected call of CountPoints.
+func (mr *MockVectorDatabaseMockRecorder) CountPoints(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPoints", reflect.TypeOf((*MockVectorDatabase)(nil).CountPoints), ctx, filter)
+}
+
+// DeletePoints mocks base method.
+func (m *MockVectorDatabase) DeletePoints(ctx context.Context, filter *qdrant.Filter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePoints", ctx, filter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePoints indicates an expected call of DeletePoints.
+func (mr *MockVectorDatabaseMockRecorder) DeletePoints(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePoints", reflect.TypeOf((*MockVectorDatabase)(nil).DeletePoints), ctx, filter)
+}
+
 // EnsureCollection mocks base method.
 func (m *MockVectorDatabase) EnsureCollection(ctx context.Context, vectorSize uint64) error {
 	m.ctrl.T.Helper()
@@ -48,20 +77,50 @@ func (mr *MockVectorDatabaseMockRecorder) EnsureCollection(ctx, vectorSize inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureCollection", reflect.TypeOf((*MockVectorDatabase)(nil).EnsureCollection), ctx, vectorSize)
 }
 
-// Search mocks base method.
-func (m *MockVectorDatabase) Search(ctx context.Context, queryEmbedding []float32, limit uint64) ([]string, []float32, error) {
+// GetVectors mocks base method.
+func (m *MockVectorDatabase) GetVectors(ctx context.Context, ids []*qdrant.PointId) (map[string][]float32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVectors", ctx, ids)
+	ret0, _ := ret[0].(map[string][]float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVectors indicates an expected call of GetVectors.
+func (mr *MockVectorDatabaseMockRecorder) GetVectors(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVectors", reflect.TypeOf((*MockVectorDatabase)(nil).GetVectors), ctx, ids)
+}
+
+// ScrollPoints mocks base method.
+func (m *MockVectorDatabase) ScrollPoints(ctx context.Context, filter *qdrant.Filter, limit uint32, offset *qdrant.PointId) ([]*qdrant.RetrievedPoint, *qdrant.PointId, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Search", ctx, queryEmbedding, limit)
-	ret0, _ := ret[0].([]string)
-	ret1, _ := ret[1].([]float32)
+	ret := m.ctrl.Call(m, "ScrollPoints", ctx, filter, limit, offset)
+	ret0, _ := ret[0].([]*qdrant.RetrievedPoint)
+	ret1, _ := ret[1].(*qdrant.PointId)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
+// ScrollPoints indicates an expected call of ScrollPoints.
+func (mr *MockVectorDatabaseMockRecorder) ScrollPoints(ctx, filter, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScrollPoints", reflect.TypeOf((*MockVectorDatabase)(nil).ScrollPoints), ctx, filter, limit, offset)
+}
+
+// Search mocks base method.
+func (m *MockVectorDatabase) Search(ctx context.Context, queryEmbedding []float32, limit uint64, opts SearchOptions) ([]SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, queryEmbedding, limit, opts)
+	ret0, _ := ret[0].([]SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Search indicates an expected call of Search.
-func (mr *MockVectorDatabaseMockRecorder) Search(ctx, queryEmbedding, limit interface{}) *gomock.Call {
+func (mr *MockVectorDatabaseMockRecorder) Search(ctx, queryEmbedding, limit, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockVectorDatabase)(nil).Search), ctx, queryEmbedding, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockVectorDatabase)(nil).Search), ctx, queryEmbedding, limit, opts)
 }
 
 // UpsertPoints mocks base method.
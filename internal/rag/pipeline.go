@@ -2,135 +2,572 @@ package rag
 
 import (
 	"context"
+	"crypto/sha1"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/qdrant/go-client/qdrant"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
 )
 
-//go:generate mockgen -source=pipeline.go -destination=mock_llmclient.go -package=rag LLMClient
+//go:generate mockgen -source=pipeline.go -destination=mock_llmclient.go -package=rag -self_package=github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag LLMClient
 
 // LLMClient defines the interface for LLM operations
 type LLMClient interface {
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
-	GenerateAnswer(ctx context.Context, contextText, question string) (string, error)
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+	GenerateAnswer(ctx context.Context, contextText, question string, history []types.ChatMessage) (*types.Answer, error)
 }
 
-//go:generate mockgen -source=pipeline.go -destination=mock_textchunker.go -package=rag TextChunker
+//go:generate mockgen -source=pipeline.go -destination=mock_textchunker.go -package=rag -self_package=github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag TextChunker
 
 // TextChunker defines the interface for text chunking operations
 type TextChunker interface {
 	ChunkText(text string) []string
 }
 
-//go:generate mockgen -source=pipeline.go -destination=mock_vectordatabase.go -package=rag VectorDatabase
+// Chunk is a piece of a document together with metadata describing where it comes from
+type Chunk struct {
+	Text string
+	// HeadingPath is the chain of headings enclosing the chunk, e.g. "Pods > Lifecycle"
+	HeadingPath string
+	// Offsets locates the chunk in the source text, if the chunker tracks it
+	Offsets *types.TextRange
+	// FilePath, Symbol, StartLine and EndLine locate a source code chunk: the file, the
+	// declaration it holds, e.g. "Pipeline.Ingest", and its 1-based line range
+	FilePath  string
+	Symbol    string
+	StartLine int
+	EndLine   int
+}
+
+// StructuredChunker is a TextChunker that also describes where each chunk comes from.
+// The pipeline stores the chunk metadata in the payload when the chunker implements it.
+type StructuredChunker interface {
+	TextChunker
+	Chunk(text string) []Chunk
+}
+
+// ContextChunker is a TextChunker that depends on external calls, such as embeddings, to chunk text.
+// The pipeline chunks with ChunkContext when the chunker implements it.
+type ContextChunker interface {
+	TextChunker
+	ChunkContext(ctx context.Context, text string) ([]Chunk, error)
+}
+
+// FileChunker is a TextChunker that chunks documents by their file type, e.g. source code by language.
+// The pipeline passes the "source" metadata field as the file path when the chunker implements it.
+type FileChunker interface {
+	TextChunker
+	ChunkFile(path, text string) []Chunk
+}
+
+//go:generate mockgen -source=pipeline.go -destination=mock_vectordatabase.go -package=rag -self_package=github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag VectorDatabase
 
 // VectorDatabase defines the interface for vector database operations
 type VectorDatabase interface {
 	EnsureCollection(ctx context.Context, vectorSize uint64) error
 	UpsertPoints(ctx context.Context, pointsToUpsert []*qdrant.PointStruct) error
-	Search(ctx context.Context, queryEmbedding []float32, limit uint64) ([]string, []float32, error)
+	DeletePoints(ctx context.Context, filter *qdrant.Filter) error
+	ScrollPoints(ctx context.Context, filter *qdrant.Filter, limit uint32, offset *qdrant.PointId) ([]*qdrant.RetrievedPoint, *qdrant.PointId, error)
+	CountPoints(ctx context.Context, filter *qdrant.Filter) (uint64, error)
+	GetVectors(ctx context.Context, ids []*qdrant.PointId) (map[string][]float32, error)
+	Search(ctx context.Context, queryEmbedding []float32, limit uint64, opts SearchOptions) ([]SearchResult, error)
+}
+
+// SearchOptions holds optional vector search settings
+type SearchOptions struct {
+	// WithVectors returns the stored vector of every hit in SearchResult.Vector
+	WithVectors bool
+	// ScoreThreshold, if positive, drops hits with a lower similarity score
+	ScoreThreshold float32
+	// Filter, if set, restricts the search to points whose payload matches it
+	Filter *qdrant.Filter
+}
+
+// ErrNoRelevantDocuments is returned by Retrieve when no chunk is relevant enough to the query
+var ErrNoRelevantDocuments = errors.New("no relevant documents found")
+
+// SearchResult represents a single chunk retrieved from the vector database
+type SearchResult struct {
+	Text       string
+	Score      float32
+	DocID      string
+	ChunkIndex int
+	// HeadingPath is the chain of headings enclosing the chunk, if the chunker recorded it
+	HeadingPath string
+	// Offsets locates the chunk in the ingested document text, if the chunker recorded it
+	Offsets *types.TextRange
+	// FilePath, Symbol, StartLine and EndLine locate a source code chunk, if the chunker recorded them
+	FilePath  string
+	Symbol    string
+	StartLine int
+	EndLine   int
+	// ParentStart and ParentEnd are the chunk indices of the enclosing section, end exclusive,
+	// if the chunk was ingested with parent references
+	ParentStart int
+	ParentEnd   int
+	// Vector is the stored embedding, only set when requested with SearchOptions.WithVectors
+	Vector []float32
+
+	// pointID identifies the stored point of a keyword index hit
+	pointID string
+	// chunkOverlap is the chunk overlap the chunk was ingested with
+	chunkOverlap int
+}
+
+// newSearchResult builds a search result from a stored chunk payload
+func newSearchResult(payload map[string]*qdrant.Value, score float32) SearchResult {
+	result := SearchResult{
+		Text:        payload["text"].GetStringValue(),
+		Score:       score,
+		DocID:       payload["doc_id"].GetStringValue(),
+		ChunkIndex:  int(payload["chunk_index"].GetIntegerValue()),
+		HeadingPath: payload["heading_path"].GetStringValue(),
+		FilePath:    payload["file_path"].GetStringValue(),
+		Symbol:      payload["symbol"].GetStringValue(),
+		StartLine:   int(payload["start_line"].GetIntegerValue()),
+		EndLine:     int(payload["end_line"].GetIntegerValue()),
+		ParentStart: int(payload["parent_start"].GetIntegerValue()),
+		ParentEnd:   int(payload["parent_end"].GetIntegerValue()),
+
+		chunkOverlap: int(payload["chunk_overlap"].GetIntegerValue()),
+	}
+	if end, ok := payload["end_offset"]; ok {
+		result.Offsets = &types.TextRange{
+			Start: int(payload["start_offset"].GetIntegerValue()),
+			End:   int(end.GetIntegerValue()),
+		}
+	}
+	return result
+}
+
+// RetrieveOptions holds per-query retrieval settings; zero values fall back to the pipeline defaults
+type RetrieveOptions struct {
+	// KeywordWeight is the share of BM25 keyword ranking in hybrid retrieval,
+	// from 0 (dense vectors only) to 1 (keywords only)
+	KeywordWeight *float64
+	// Filter restricts retrieval to chunks whose payload matches it
+	Filter *types.QueryFilter
 }
 
 // Pipeline orchestrates the RAG pipeline
 type Pipeline struct {
-	chunker      TextChunker
-	llmClient    LLMClient
-	qdrantClient VectorDatabase
-	searchLimit  int
+	chunker       TextChunker
+	llmClient     LLMClient
+	qdrantClient  VectorDatabase
+	searchLimit   int
+	keywordIndex  *BM25Index
+	keywordSync   keywordSync
+	keywordWeight float64
+
+	reranker         Reranker
+	rerankCandidates int
+
+	mmr           bool
+	mmrLambda     float64
+	mmrCandidates int
+
+	minScore float32
+
+	embeddingModel string
+	chunkOverlap   int
+
+	expansion       int
+	expansionWindow int
+}
+
+// PipelineOption configures optional Pipeline behaviour
+type PipelineOption func(*Pipeline)
+
+// WithKeywordWeight sets the default share of BM25 keyword ranking in hybrid retrieval
+func WithKeywordWeight(weight float64) PipelineOption {
+	return func(p *Pipeline) {
+		p.keywordWeight = weight
+	}
+}
+
+// WithReranker reranks the top candidates of every search before the best searchLimit
+// of them are returned. candidates is how many results are fetched for reranking.
+func WithReranker(reranker Reranker, candidates int) PipelineOption {
+	return func(p *Pipeline) {
+		p.reranker = reranker
+		p.rerankCandidates = candidates
+	}
 }
 
-// NewPipeline creates a new RAG pipeline
-func NewPipeline(chunker TextChunker, llmClient LLMClient, qdrantClient VectorDatabase, searchLimit int) (*Pipeline, error) {
+// WithMMR selects the returned chunks with Maximal Marginal Relevance from the top candidates,
+// trading relevance (lambda = 1) against diversity (lambda = 0)
+func WithMMR(lambda float64, candidates int) PipelineOption {
+	return func(p *Pipeline) {
+		p.mmr = true
+		p.mmrLambda = lambda
+		p.mmrCandidates = candidates
+	}
+}
+
+// WithMinScore drops vector search hits with a similarity score below minScore
+func WithMinScore(minScore float32) PipelineOption {
+	return func(p *Pipeline) {
+		p.minScore = minScore
+	}
+}
+
+// WithEmbeddingModel records the embedding model in the payload of every ingested chunk.
+// Stored vectors are only reused on re-ingest if they were produced by the same model.
+func WithEmbeddingModel(model string) PipelineOption {
+	return func(p *Pipeline) {
+		p.embeddingModel = model
+	}
+}
+
+// WithChunkOverlap records in the payload of every ingested chunk that the chunker repeats
+// the end of each chunk at the start of the next one. Context expansion only drops words
+// repeated at a chunk boundary when the chunks were ingested with an overlap.
+func WithChunkOverlap(overlap int) PipelineOption {
+	return func(p *Pipeline) {
+		p.chunkOverlap = overlap
+	}
+}
+
+// NewPipeline creates a new RAG pipeline.
+// vectorSize is the dimension of the embeddings produced by llmClient.
+func NewPipeline(chunker TextChunker, llmClient LLMClient, qdrantClient VectorDatabase, vectorSize uint64, searchLimit int, opts ...PipelineOption) (*Pipeline, error) {
 	// Ensure collection exists with correct vector size
-	// text-embedding-3-large produces 3072-dimensional vectors
-	vectorSize := uint64(3072)
 	ctx := context.Background()
 	if err := qdrantClient.EnsureCollection(ctx, vectorSize); err != nil {
 		return nil, fmt.Errorf("failed to ensure collection: %w", err)
 	}
 
-	return &Pipeline{
+	p := &Pipeline{
 		chunker:      chunker,
 		llmClient:    llmClient,
 		qdrantClient: qdrantClient,
 		searchLimit:  searchLimit,
-	}, nil
+		keywordIndex: NewBM25Index(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
 }
 
-// Ingest processes and stores a document in the vector database
-func (p *Pipeline) Ingest(ctx context.Context, text string, docID string) error {
+// Ingest processes and stores a document in the vector database.
+// metadata is stored in the payload of every chunk next to the text and can be used in query filters.
+// When a document is re-ingested, only chunks whose payload changed are written, and chunks whose
+// text is unchanged reuse their stored embedding, matched by the SHA-256 of the text.
+// The SHA-256 of the whole document is stored on its first chunk; a document whose text and
+// metadata equal the stored version is not chunked again.
+func (p *Pipeline) Ingest(ctx context.Context, text string, docID string, metadata map[string]any) error {
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
+
+	// Load the previous version of the document to skip the chunks that did not change
+	docHash := contentHash(text)
+	var stored map[int]storedChunk
+	if docID != "" {
+		var err error
+		stored, err = p.storedChunks(ctx, docID)
+		if err != nil {
+			return fmt.Errorf("failed to load stored chunks: %w", err)
+		}
+		if unchangedDocument(stored, docHash, p.embeddingModel, metadata) {
+			return nil
+		}
+	}
+
 	// Chunk the text
-	chunks := p.chunker.ChunkText(text)
+	path, _ := metadata["source"].(string)
+	chunks, err := p.chunkText(ctx, text, path)
+	if err != nil {
+		return fmt.Errorf("failed to chunk text: %w", err)
+	}
 
 	if len(chunks) == 0 {
 		return fmt.Errorf("no chunks created from text")
 	}
 
-	// Generate embeddings for each chunk and prepare points
+	ingestedAt := time.Now().UTC().Format(time.RFC3339)
+	parents := parentRanges(chunks)
+
+	// Prepare points
 	pointsToUpsert := make([]*qdrant.PointStruct, 0, len(chunks))
 
 	for i, chunk := range chunks {
-		// Generate embedding
-		embedding, err := p.llmClient.GenerateEmbedding(ctx, chunk)
-		if err != nil {
-			return fmt.Errorf("failed to generate embedding for chunk %d: %w", i, err)
-		}
-
-		// Create point ID (use docID + chunk index if docID provided, otherwise use timestamp)
-		var pointID uint64
+		// Create point ID (derive from docID + chunk index if docID provided, otherwise use timestamp)
+		var pointID *qdrant.PointId
 		if docID != "" {
-			pointID = uint64(i) // Simple ID based on chunk index
+			pointID = qdrant.NewID(chunkPointID(docID, i))
 		} else {
-			pointID = uint64(time.Now().UnixNano()) + uint64(i)
+			pointID = qdrant.NewIDNum(uint64(time.Now().UnixNano()) + uint64(i))
 		}
 
 		// Create point with payload using Qdrant helper functions
-		point := &qdrant.PointStruct{
-			Id:      qdrant.NewIDNum(pointID),
-			Vectors: qdrant.NewVectors(embedding...),
-			Payload: qdrant.NewValueMap(map[string]any{
-				"text":        chunk,
-				"doc_id":      docID,
-				"chunk_index": int64(i),
-			}),
+		fields := map[string]any{
+			"text":        chunk.Text,
+			"doc_id":      docID,
+			"chunk_index": int64(i),
+			"ingested_at": ingestedAt,
+			"chunk_hash":  contentHash(chunk.Text),
+		}
+		if parents != nil {
+			fields["parent_start"] = int64(parents[i][0])
+			fields["parent_end"] = int64(parents[i][1])
+		}
+		// The document hash and chunk count are kept on the first chunk only, so that an edit rewrites
+		// the changed chunks and the first one rather than every chunk of the document
+		if i == 0 {
+			fields["doc_hash"] = docHash
+			fields["chunk_count"] = int64(len(chunks))
+		}
+		if p.embeddingModel != "" {
+			fields["embedding_model"] = p.embeddingModel
+		}
+		if p.chunkOverlap > 0 {
+			fields["chunk_overlap"] = int64(p.chunkOverlap)
+		}
+		if chunk.HeadingPath != "" {
+			fields["heading_path"] = chunk.HeadingPath
+		}
+		if chunk.Offsets != nil {
+			fields["start_offset"] = int64(chunk.Offsets.Start)
+			fields["end_offset"] = int64(chunk.Offsets.End)
+		}
+		if chunk.FilePath != "" {
+			fields["file_path"] = chunk.FilePath
+		}
+		if chunk.Symbol != "" {
+			fields["symbol"] = chunk.Symbol
+		}
+		if chunk.StartLine > 0 {
+			fields["start_line"] = int64(chunk.StartLine)
+			fields["end_line"] = int64(chunk.EndLine)
+		}
+		for key, value := range metadata {
+			fields[key] = value
+		}
+		payload, err := qdrant.TryValueMap(fields)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+		}
+
+		if previous, ok := stored[i]; ok && samePayload(previous.payload, payload) {
+			continue
 		}
 
-		pointsToUpsert = append(pointsToUpsert, point)
+		pointsToUpsert = append(pointsToUpsert, &qdrant.PointStruct{
+			Id:      pointID,
+			Payload: payload,
+		})
+	}
+
+	// Embed the changed chunks, reusing the stored vectors of chunks with the same text
+	if err := p.setVectors(ctx, pointsToUpsert, stored); err != nil {
+		return err
 	}
 
 	// Upsert points to Qdrant
-	if err := p.qdrantClient.UpsertPoints(ctx, pointsToUpsert); err != nil {
-		return fmt.Errorf("failed to upsert points: %w", err)
+	if len(pointsToUpsert) > 0 {
+		if err := p.qdrantClient.UpsertPoints(ctx, pointsToUpsert); err != nil {
+			return fmt.Errorf("failed to upsert points: %w", err)
+		}
+	}
+	p.keywordIndexUpsert(pointsToUpsert)
+
+	// Remove stale tail chunks left over from a longer previous version of the document
+	if docID != "" && hasStaleChunks(stored, len(chunks)) {
+		firstStale := float64(len(chunks))
+		filter := &qdrant.Filter{
+			Must: []*qdrant.Condition{
+				qdrant.NewMatchKeyword("doc_id", docID),
+				qdrant.NewRange("chunk_index", &qdrant.Range{Gte: &firstStale}),
+			},
+		}
+		if err := p.qdrantClient.DeletePoints(ctx, filter); err != nil {
+			return fmt.Errorf("failed to delete stale chunks: %w", err)
+		}
+		p.keywordIndexDelete(docID, len(chunks))
 	}
 
 	return nil
 }
 
-// Retrieve searches for relevant context based on a query
-func (p *Pipeline) Retrieve(ctx context.Context, query string) (string, error) {
-	// Generate embedding for the query
-	queryEmbedding, err := p.llmClient.GenerateEmbedding(ctx, query)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate query embedding: %w", err)
+// chunkText splits text with the configured chunker, keeping chunk metadata if the chunker provides it.
+// path is the file path of the document, if known.
+func (p *Pipeline) chunkText(ctx context.Context, text, path string) ([]Chunk, error) {
+	switch chunker := p.chunker.(type) {
+	case ContextChunker:
+		return chunker.ChunkContext(ctx, text)
+	case FileChunker:
+		return chunker.ChunkFile(path, text), nil
+	case StructuredChunker:
+		return chunker.Chunk(text), nil
 	}
 
-	// Search for similar documents
-	texts, scores, err := p.qdrantClient.Search(ctx, queryEmbedding, uint64(p.searchLimit))
-	if err != nil {
-		return "", fmt.Errorf("failed to search: %w", err)
+	texts := p.chunker.ChunkText(text)
+	chunks := make([]Chunk, len(texts))
+	for i, chunkText := range texts {
+		chunks[i] = Chunk{Text: chunkText}
+	}
+	return chunks, nil
+}
+
+// Delete removes all chunks of a document from the vector database
+func (p *Pipeline) Delete(ctx context.Context, docID string) error {
+	if docID == "" {
+		return fmt.Errorf("document ID is required")
+	}
+
+	if err := p.qdrantClient.DeletePoints(ctx, documentFilter(docID)); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	p.keywordIndexDelete(docID, 0)
+
+	return nil
+}
+
+// Retrieve searches for relevant chunks based on a query.
+// With a positive keyword weight, dense and BM25 keyword results are fused by reciprocal rank.
+// With a reranker, the fused candidates are reranked and the best searchLimit are kept.
+// With MMR, the kept chunks are chosen to be both relevant and distinct from each other.
+// With context expansion, the text of every kept chunk is replaced with its neighbours or parent section.
+// It returns ErrNoRelevantDocuments if no chunk passes the minimum score; with a minimum score,
+// keyword hits whose stored vector is less similar to the query are dropped before fusion.
+func (p *Pipeline) Retrieve(ctx context.Context, query string, opts RetrieveOptions) ([]SearchResult, error) {
+	keywordWeight := p.keywordWeight
+	if opts.KeywordWeight != nil {
+		keywordWeight = *opts.KeywordWeight
+	}
+
+	// Over-fetch candidates when a reranker or MMR picks the best of them
+	limit := p.searchLimit
+	if p.reranker != nil {
+		limit = max(limit, p.rerankCandidates)
+	}
+	if p.mmr {
+		limit = max(limit, p.mmrCandidates)
+	}
+
+	fetchLimit := limit
+	if keywordWeight > 0 {
+		fetchLimit *= hybridCandidateFactor
+	}
+
+	filter := buildQueryFilter(opts.Filter)
+
+	// The minimum score is a similarity to the query embedding, so keyword only queries
+	// need the embedding as well to check it
+	var queryEmbedding []float32
+	if keywordWeight < 1 || p.minScore > 0 {
+		var err error
+		queryEmbedding, err = p.llmClient.GenerateEmbedding(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+		}
+	}
+
+	var results []SearchResult
+	if keywordWeight < 1 {
+		// Search for similar documents
+		var err error
+		results, err = p.qdrantClient.Search(ctx, queryEmbedding, uint64(fetchLimit), SearchOptions{
+			WithVectors:    p.mmr,
+			ScoreThreshold: p.minScore,
+			Filter:         filter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search: %w", err)
+		}
+	}
+
+	if keywordWeight > 0 {
+		if err := p.syncKeywordIndex(ctx); err != nil {
+			return nil, err
+		}
+		keywordResults, err := p.keywordIndex.Search(query, fetchLimit, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search keyword index: %w", err)
+		}
+		if p.minScore > 0 {
+			keywordResults, err = p.keywordHitsAboveMinScore(ctx, queryEmbedding, keywordResults)
+			if err != nil {
+				return nil, err
+			}
+		}
+		results = fuseRRF(results, keywordResults, keywordWeight, limit)
+	}
+
+	if p.reranker != nil && len(results) > 0 {
+		reranked, err := p.reranker.Rerank(ctx, query, results)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rerank: %w", err)
+		}
+		results = reranked
 	}
 
-	if len(texts) == 0 {
-		return "", fmt.Errorf("no relevant documents found")
+	if p.mmr {
+		results = selectMMR(results, p.mmrLambda, p.searchLimit)
+	} else {
+		results = results[:min(len(results), p.searchLimit)]
 	}
 
-	// Combine retrieved texts into context
+	if len(results) == 0 {
+		return nil, ErrNoRelevantDocuments
+	}
+
+	if p.expansion != expandNone {
+		expanded, err := p.expandResults(ctx, results)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand context: %w", err)
+		}
+		results = expanded
+	}
+
+	return results, nil
+}
+
+// BuildContext combines retrieved chunks into a context string for the LLM
+func BuildContext(results []SearchResult) string {
 	var contextBuilder strings.Builder
-	for i, text := range texts {
-		contextBuilder.WriteString(fmt.Sprintf("[Document %d, Score: %.4f]\n%s\n\n", i+1, scores[i], text))
+	for i, result := range results {
+		header := fmt.Sprintf("Document %d, Score: %.4f", i+1, result.Score)
+		if result.HeadingPath != "" {
+			header += fmt.Sprintf(", Section: %s", result.HeadingPath)
+		}
+		if result.FilePath != "" {
+			header += fmt.Sprintf(", File: %s:%d-%d", result.FilePath, result.StartLine, result.EndLine)
+		}
+		if result.Symbol != "" {
+			header += fmt.Sprintf(", Symbol: %s", result.Symbol)
+		}
+		contextBuilder.WriteString(fmt.Sprintf("[%s]\n%s\n\n", header, result.Text))
 	}
 
-	return strings.TrimSpace(contextBuilder.String()), nil
+	return strings.TrimSpace(contextBuilder.String())
+}
+
+// pointIDNamespace is the UUID namespace used to derive chunk point IDs
+var pointIDNamespace = [16]byte{
+	0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1,
+	0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8,
+}
+
+// chunkPointID returns a deterministic UUIDv5 for the given document chunk,
+// so re-ingesting a document overwrites exactly its own points
+func chunkPointID(docID string, chunkIndex int) string {
+	h := sha1.New()
+	h.Write(pointIDNamespace[:])
+	fmt.Fprintf(h, "%s#%d", docID, chunkIndex)
+	sum := h.Sum(nil)
+
+	var u [16]byte
+	copy(u[:], sum[:16])
+	u[6] = (u[6] & 0x0f) | 0x50 // version 5
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
 }
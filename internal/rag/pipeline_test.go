@@ -3,6 +3,7 @@ package rag
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -16,6 +17,7 @@ func TestNewPipeline(t *testing.T) {
 		chunker      TextChunker
 		llmClient    LLMClient
 		qdrantClient VectorDatabase
+		vectorSize   uint64
 		searchLimit  int
 		setupMocks   func(*MockVectorDatabase)
 		wantErr      bool
@@ -24,15 +26,38 @@ func TestNewPipeline(t *testing.T) {
 		{
 			name:        "successful creation",
 			chunker:     NewChunker(100, 20),
+			vectorSize:  3072,
 			searchLimit: 3,
 			setupMocks: func(m *MockVectorDatabase) {
 				m.EXPECT().EnsureCollection(gomock.Any(), uint64(3072)).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name:        "collection sized for detected dimension",
+			chunker:     NewChunker(100, 20),
+			vectorSize:  1536,
+			searchLimit: 3,
+			setupMocks: func(m *MockVectorDatabase) {
+				m.EXPECT().EnsureCollection(gomock.Any(), uint64(1536)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "existing collection has different dimension",
+			chunker:     NewChunker(100, 20),
+			vectorSize:  1536,
+			searchLimit: 3,
+			setupMocks: func(m *MockVectorDatabase) {
+				m.EXPECT().EnsureCollection(gomock.Any(), uint64(1536)).Return(fmt.Errorf("%w: stores 3072-dimensional vectors", ErrVectorSizeMismatch))
+			},
+			wantErr:     true,
+			errContains: "collection vector size mismatch",
+		},
 		{
 			name:        "collection creation fails",
 			chunker:     NewChunker(100, 20),
+			vectorSize:  3072,
 			searchLimit: 3,
 			setupMocks: func(m *MockVectorDatabase) {
 				m.EXPECT().EnsureCollection(gomock.Any(), uint64(3072)).Return(errors.New("connection failed"))
@@ -53,7 +78,7 @@ func TestNewPipeline(t *testing.T) {
 				tt.setupMocks(mockQdrant)
 			}
 
-			pipeline, err := NewPipeline(tt.chunker, mockLLM, mockQdrant, tt.searchLimit)
+			pipeline, err := NewPipeline(tt.chunker, mockLLM, mockQdrant, tt.vectorSize, tt.searchLimit)
 
 			if tt.wantErr {
 				if err == nil {
@@ -111,26 +136,86 @@ func TestPipeline_Ingest(t *testing.T) {
 					embedding3[i] = float32(i) * 0.003
 				}
 
-				llm.EXPECT().GenerateEmbedding(gomock.Any(), "This is a test").Return(embedding1, nil)
-				llm.EXPECT().GenerateEmbedding(gomock.Any(), "test document with").Return(embedding2, nil)
-				llm.EXPECT().GenerateEmbedding(gomock.Any(), "with multiple words").Return(embedding3, nil)
+				// The previous version of the document had five chunks
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(storedPoints("doc1", 0, 1, 2, 3, 4), nil, nil)
+				llm.EXPECT().GenerateEmbeddings(gomock.Any(), chunks).Return([][]float32{embedding1, embedding2, embedding3}, nil)
 
 				db.EXPECT().UpsertPoints(gomock.Any(), gomock.Any()).DoAndReturn(
 					func(ctx context.Context, points []*qdrant.PointStruct) error {
 						if len(points) != 3 {
 							return errors.New("unexpected number of points")
 						}
+						// Embeddings must stay aligned with their chunks
+						for i, point := range points {
+							vector := point.GetVectors().GetVector().GetDense().GetData()
+							if len(vector) != 3072 || vector[1] != float32(i+1)*0.001 {
+								return errors.New("embedding attached to wrong chunk")
+							}
+							if point.GetPayload()["text"].GetStringValue() != chunks[i] {
+								return errors.New("chunk text out of order")
+							}
+						}
+						return nil
+					},
+				)
+				db.EXPECT().DeletePoints(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, filter *qdrant.Filter) error {
+						if len(filter.GetMust()) != 2 {
+							return errors.New("unexpected stale chunk filter")
+						}
+						if got := filter.GetMust()[0].GetField().GetMatch().GetKeyword(); got != "doc1" {
+							return errors.New("unexpected doc_id in filter: " + got)
+						}
+						if got := filter.GetMust()[1].GetField().GetRange().GetGte(); got != 3 {
+							return errors.New("unexpected chunk_index lower bound")
+						}
 						return nil
 					},
 				)
 			},
 			wantErr: false,
 		},
+		{
+			name:  "ingestion without docID keeps other points",
+			text:  "test document",
+			docID: "",
+			setupMocks: func(chunker *MockTextChunker, llm *MockLLMClient, db *MockVectorDatabase) {
+				chunker.EXPECT().ChunkText("test document").Return([]string{"test document"})
+				llm.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"test document"}).Return([][]float32{make([]float32, 3072)}, nil)
+				db.EXPECT().UpsertPoints(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:  "stale chunk deletion fails",
+			text:  "test document",
+			docID: "doc1",
+			setupMocks: func(chunker *MockTextChunker, llm *MockLLMClient, db *MockVectorDatabase) {
+				chunker.EXPECT().ChunkText("test document").Return([]string{"test document"})
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(storedPoints("doc1", 0, 1), nil, nil)
+				llm.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"test document"}).Return([][]float32{make([]float32, 3072)}, nil)
+				db.EXPECT().UpsertPoints(gomock.Any(), gomock.Any()).Return(nil)
+				db.EXPECT().DeletePoints(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+			},
+			wantErr:     true,
+			errContains: "failed to delete stale chunks",
+		},
+		{
+			name:  "unchanged document is not chunked again",
+			text:  "old text",
+			docID: "doc1",
+			setupMocks: func(chunker *MockTextChunker, llm *MockLLMClient, db *MockVectorDatabase) {
+				stored := storedPoints("doc1", 0)
+				stored[0].Payload["doc_hash"] = qdrant.NewValueString(contentHash("old text"))
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(stored, nil, nil)
+			},
+		},
 		{
 			name:  "empty text after chunking",
 			text:  "short",
 			docID: "doc1",
 			setupMocks: func(chunker *MockTextChunker, llm *MockLLMClient, db *MockVectorDatabase) {
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil)
 				chunker.EXPECT().ChunkText("short").Return([]string{})
 			},
 			wantErr:     true,
@@ -142,10 +227,23 @@ func TestPipeline_Ingest(t *testing.T) {
 			docID: "doc1",
 			setupMocks: func(chunker *MockTextChunker, llm *MockLLMClient, db *MockVectorDatabase) {
 				chunker.EXPECT().ChunkText("test document").Return([]string{"test document"})
-				llm.EXPECT().GenerateEmbedding(gomock.Any(), "test document").Return(nil, errors.New("API error"))
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil)
+				llm.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"test document"}).Return(nil, errors.New("API error"))
+			},
+			wantErr:     true,
+			errContains: "failed to generate embeddings",
+		},
+		{
+			name:  "embedding count mismatch",
+			text:  "test document",
+			docID: "doc1",
+			setupMocks: func(chunker *MockTextChunker, llm *MockLLMClient, db *MockVectorDatabase) {
+				chunker.EXPECT().ChunkText("test document").Return([]string{"test", "document"})
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil)
+				llm.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"test", "document"}).Return([][]float32{make([]float32, 3072)}, nil)
 			},
 			wantErr:     true,
-			errContains: "failed to generate embedding",
+			errContains: "got 1 embeddings for 2 chunks",
 		},
 		{
 			name:  "upsert fails",
@@ -153,11 +251,12 @@ func TestPipeline_Ingest(t *testing.T) {
 			docID: "doc1",
 			setupMocks: func(chunker *MockTextChunker, llm *MockLLMClient, db *MockVectorDatabase) {
 				chunker.EXPECT().ChunkText("test document").Return([]string{"test document"})
+				db.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil)
 				embedding := make([]float32, 3072)
 				for i := range embedding {
 					embedding[i] = float32(i) * 0.001
 				}
-				llm.EXPECT().GenerateEmbedding(gomock.Any(), "test document").Return(embedding, nil)
+				llm.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"test document"}).Return([][]float32{embedding}, nil)
 				db.EXPECT().UpsertPoints(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
 			},
 			wantErr:     true,
@@ -181,12 +280,12 @@ func TestPipeline_Ingest(t *testing.T) {
 				tt.setupMocks(mockChunker, mockLLM, mockDB)
 			}
 
-			pipeline, err := NewPipeline(mockChunker, mockLLM, mockDB, 3)
+			pipeline, err := NewPipeline(mockChunker, mockLLM, mockDB, 3072, 3)
 			if err != nil {
 				t.Fatalf("NewPipeline() failed: %v", err)
 			}
 
-			err = pipeline.Ingest(context.Background(), tt.text, tt.docID)
+			err = pipeline.Ingest(context.Background(), tt.text, tt.docID, nil)
 
 			if tt.wantErr {
 				if err == nil {
@@ -208,6 +307,196 @@ func TestPipeline_Ingest(t *testing.T) {
 	}
 }
 
+// storedPoints returns stored chunks of a document with the given chunk indices and outdated texts
+func storedPoints(docID string, indices ...int) []*qdrant.RetrievedPoint {
+	points := make([]*qdrant.RetrievedPoint, len(indices))
+	for i, index := range indices {
+		point := newMemoryPoint(docID, int64(index), "old text", nil)
+		points[i] = &qdrant.RetrievedPoint{Id: point.GetId(), Payload: point.GetPayload()}
+	}
+	return points
+}
+
+func TestChunkPointID(t *testing.T) {
+	tests := []struct {
+		name       string
+		docID      string
+		chunkIndex int
+		want       string
+	}{
+		{
+			name:       "first chunk",
+			docID:      "kubernetes_1.txt",
+			chunkIndex: 0,
+			want:       "8a19209b-8fe3-581f-96e6-67f3951f453c",
+		},
+		{
+			name:       "second chunk of same document",
+			docID:      "kubernetes_1.txt",
+			chunkIndex: 1,
+			want:       "daf4f886-123b-54db-854d-c7c3532ff956",
+		},
+		{
+			name:       "first chunk of another document",
+			docID:      "kubernetes_2.txt",
+			chunkIndex: 0,
+			want:       "ed7e4266-f2d1-5441-bd07-4213b27942eb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkPointID(tt.docID, tt.chunkIndex)
+			if got != tt.want {
+				t.Errorf("chunkPointID(%q, %d) = %q, want %q", tt.docID, tt.chunkIndex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_Ingest_PointIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChunker := NewMockTextChunker(ctrl)
+	mockLLM := NewMockLLMClient(ctrl)
+	mockDB := NewMockVectorDatabase(ctrl)
+
+	mockDB.EXPECT().EnsureCollection(gomock.Any(), uint64(3072)).Return(nil)
+	mockChunker.EXPECT().ChunkText(gomock.Any()).Return([]string{"chunk one", "chunk two"}).Times(3)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return([][]float32{make([]float32, 3072), make([]float32, 3072)}, nil).Times(3)
+
+	var upserted [][]string
+	mockDB.EXPECT().UpsertPoints(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, points []*qdrant.PointStruct) error {
+			ids := make([]string, 0, len(points))
+			for _, point := range points {
+				ids = append(ids, point.GetId().GetUuid())
+			}
+			upserted = append(upserted, ids)
+			return nil
+		},
+	).Times(3)
+	// The mock stores nothing, so every ingest writes all chunks and finds no stale ones
+	mockDB.EXPECT().ScrollPoints(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil).Times(3)
+
+	pipeline, err := NewPipeline(mockChunker, mockLLM, mockDB, 3072, 3)
+	if err != nil {
+		t.Fatalf("NewPipeline() failed: %v", err)
+	}
+
+	for _, docID := range []string{"doc1", "doc2", "doc1"} {
+		if err := pipeline.Ingest(context.Background(), "text", docID, nil); err != nil {
+			t.Fatalf("Ingest(%q) unexpected error: %v", docID, err)
+		}
+	}
+
+	doc1, doc2, doc1Again := upserted[0], upserted[1], upserted[2]
+
+	seen := make(map[string]bool)
+	for _, id := range append(append([]string{}, doc1...), doc2...) {
+		if id == "" {
+			t.Fatalf("Ingest() produced point without UUID")
+		}
+		if seen[id] {
+			t.Errorf("Ingest() produced duplicate point ID %q across documents", id)
+		}
+		seen[id] = true
+	}
+
+	for i := range doc1 {
+		if doc1[i] != doc1Again[i] {
+			t.Errorf("re-ingest point ID[%d] = %q, want %q", i, doc1Again[i], doc1[i])
+		}
+	}
+}
+
+func TestPipeline_Delete(t *testing.T) {
+	tests := []struct {
+		name        string
+		docID       string
+		setupMocks  func(*MockVectorDatabase)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:  "successful deletion",
+			docID: "doc1",
+			setupMocks: func(db *MockVectorDatabase) {
+				db.EXPECT().DeletePoints(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, filter *qdrant.Filter) error {
+						if len(filter.GetMust()) != 1 {
+							return errors.New("unexpected filter")
+						}
+						if got := filter.GetMust()[0].GetField().GetMatch().GetKeyword(); got != "doc1" {
+							return errors.New("unexpected doc_id in filter: " + got)
+						}
+						return nil
+					},
+				)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "empty docID",
+			docID:       "",
+			setupMocks:  func(*MockVectorDatabase) {},
+			wantErr:     true,
+			errContains: "document ID is required",
+		},
+		{
+			name:  "delete fails",
+			docID: "doc1",
+			setupMocks: func(db *MockVectorDatabase) {
+				db.EXPECT().DeletePoints(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+			},
+			wantErr:     true,
+			errContains: "failed to delete document",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockChunker := NewMockTextChunker(ctrl)
+			mockLLM := NewMockLLMClient(ctrl)
+			mockDB := NewMockVectorDatabase(ctrl)
+
+			mockDB.EXPECT().EnsureCollection(gomock.Any(), uint64(3072)).Return(nil)
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockDB)
+			}
+
+			pipeline, err := NewPipeline(mockChunker, mockLLM, mockDB, 3072, 3)
+			if err != nil {
+				t.Fatalf("NewPipeline() failed: %v", err)
+			}
+
+			err = pipeline.Delete(context.Background(), tt.docID)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Delete() expected error but got nil")
+					return
+				}
+				if tt.errContains != "" {
+					if !strings.Contains(err.Error(), tt.errContains) {
+						t.Errorf("Delete() error = %v, want error containing %q", err, tt.errContains)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Delete() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func TestPipeline_Retrieve(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -227,9 +516,11 @@ func TestPipeline_Retrieve(t *testing.T) {
 				}
 				llm.EXPECT().GenerateEmbedding(gomock.Any(), "test query").Return(queryEmbedding, nil)
 
-				texts := []string{"Document 1", "Document 2"}
-				scores := []float32{0.9, 0.8}
-				db.EXPECT().Search(gomock.Any(), queryEmbedding, uint64(3)).Return(texts, scores, nil)
+				results := []SearchResult{
+					{Text: "Document 1", Score: 0.9, DocID: "doc1", ChunkIndex: 0},
+					{Text: "Document 2", Score: 0.8, DocID: "doc2", ChunkIndex: 4},
+				}
+				db.EXPECT().Search(gomock.Any(), queryEmbedding, uint64(3), SearchOptions{}).Return(results, nil)
 			},
 			wantErr:      false,
 			wantContains: "Document 1",
@@ -252,7 +543,7 @@ func TestPipeline_Retrieve(t *testing.T) {
 					queryEmbedding[i] = float32(i) * 0.001
 				}
 				llm.EXPECT().GenerateEmbedding(gomock.Any(), "test query").Return(queryEmbedding, nil)
-				db.EXPECT().Search(gomock.Any(), queryEmbedding, uint64(3)).Return(nil, nil, errors.New("search error"))
+				db.EXPECT().Search(gomock.Any(), queryEmbedding, uint64(3), SearchOptions{}).Return(nil, errors.New("search error"))
 			},
 			wantErr:     true,
 			errContains: "failed to search",
@@ -266,7 +557,7 @@ func TestPipeline_Retrieve(t *testing.T) {
 					queryEmbedding[i] = float32(i) * 0.001
 				}
 				llm.EXPECT().GenerateEmbedding(gomock.Any(), "test query").Return(queryEmbedding, nil)
-				db.EXPECT().Search(gomock.Any(), queryEmbedding, uint64(3)).Return([]string{}, []float32{}, nil)
+				db.EXPECT().Search(gomock.Any(), queryEmbedding, uint64(3), SearchOptions{}).Return([]SearchResult{}, nil)
 			},
 			wantErr:     true,
 			errContains: "no relevant documents found",
@@ -289,12 +580,12 @@ func TestPipeline_Retrieve(t *testing.T) {
 				tt.setupMocks(mockLLM, mockDB)
 			}
 
-			pipeline, err := NewPipeline(mockChunker, mockLLM, mockDB, 3)
+			pipeline, err := NewPipeline(mockChunker, mockLLM, mockDB, 3072, 3)
 			if err != nil {
 				t.Fatalf("NewPipeline() failed: %v", err)
 			}
 
-			result, err := pipeline.Retrieve(context.Background(), tt.query)
+			result, err := pipeline.Retrieve(context.Background(), tt.query, RetrieveOptions{})
 
 			if tt.wantErr {
 				if err == nil {
@@ -315,10 +606,46 @@ func TestPipeline_Retrieve(t *testing.T) {
 			}
 
 			if tt.wantContains != "" {
-				if !strings.Contains(result, tt.wantContains) {
-					t.Errorf("Retrieve() result = %q, want containing %q", result, tt.wantContains)
+				if len(result) == 0 || result[0].Text != tt.wantContains {
+					t.Errorf("Retrieve() result = %+v, want first hit %q", result, tt.wantContains)
 				}
 			}
 		})
 	}
 }
+
+func TestPipeline_Retrieve_MinScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockDB := NewMockVectorDatabase(ctrl)
+
+	mockDB.EXPECT().EnsureCollection(gomock.Any(), uint64(2)).Return(nil)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "off-topic question").Return([]float32{1, 0}, nil)
+	mockDB.EXPECT().Search(gomock.Any(), []float32{1, 0}, uint64(3), SearchOptions{ScoreThreshold: 0.4}).Return(nil, nil)
+
+	pipeline, err := NewPipeline(NewChunker(100, 0), mockLLM, mockDB, 2, 3, WithMinScore(0.4))
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	_, err = pipeline.Retrieve(context.Background(), "off-topic question", RetrieveOptions{})
+	if !errors.Is(err, ErrNoRelevantDocuments) {
+		t.Errorf("Retrieve() error = %v, want ErrNoRelevantDocuments", err)
+	}
+}
+
+func TestBuildContext(t *testing.T) {
+	results := []SearchResult{
+		{Text: "First chunk", Score: 0.9, DocID: "doc1", ChunkIndex: 0},
+		{Text: "Second chunk", Score: 0.75, DocID: "doc2", ChunkIndex: 3},
+	}
+
+	got := BuildContext(results)
+	want := "[Document 1, Score: 0.9000]\nFirst chunk\n\n[Document 2, Score: 0.7500]\nSecond chunk"
+
+	if got != want {
+		t.Errorf("BuildContext() = %q, want %q", got, want)
+	}
+}
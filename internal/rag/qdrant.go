@@ -2,11 +2,15 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/qdrant/go-client/qdrant"
 )
 
+// ErrVectorSizeMismatch is returned when an existing collection was created for a different embedding dimension
+var ErrVectorSizeMismatch = errors.New("collection vector size mismatch")
+
 // QdrantClient wraps Qdrant client and provides RAG-specific methods
 type QdrantClient struct {
 	client     *qdrant.Client
@@ -33,10 +37,26 @@ func NewQdrantClient(host string, port int, collection string) (*QdrantClient, e
 
 // EnsureCollection ensures the collection exists with the correct configuration
 func (qc *QdrantClient) EnsureCollection(ctx context.Context, vectorSize uint64) error {
-	// Check if collection exists by trying to get it
-	_, err := qc.client.GetCollectionInfo(ctx, qc.collection)
-	if err == nil {
-		return nil // Collection exists
+	exists, err := qc.client.CollectionExists(ctx, qc.collection)
+	if err != nil {
+		return fmt.Errorf("failed to check collection: %w", err)
+	}
+
+	// Validate existing collection against the embedding dimension
+	if exists {
+		info, err := qc.client.GetCollectionInfo(ctx, qc.collection)
+		if err != nil {
+			return fmt.Errorf("failed to get collection info: %w", err)
+		}
+
+		existingSize := info.GetConfig().GetParams().GetVectorsConfig().GetParams().GetSize()
+		if existingSize != vectorSize {
+			return fmt.Errorf("%w: collection %q stores %d-dimensional vectors, but the embedding model produces %d; "+
+				"use a different collection or re-create it", ErrVectorSizeMismatch, qc.collection, existingSize, vectorSize)
+		}
+
+		// Collections created by older versions may lack some of the payload indexes
+		return qc.ensurePayloadIndexes(ctx)
 	}
 
 	// Create collection if it doesn't exist
@@ -51,6 +71,35 @@ func (qc *QdrantClient) EnsureCollection(ctx context.Context, vectorSize uint64)
 		return fmt.Errorf("failed to create collection: %w", err)
 	}
 
+	return qc.ensurePayloadIndexes(ctx)
+}
+
+// ensurePayloadIndexes indexes payload fields used by per-document filters (delete, replace, listing)
+// and query filters. Creating an index that already exists is a no-op.
+func (qc *QdrantClient) ensurePayloadIndexes(ctx context.Context) error {
+	wait := true
+	payloadIndexes := []struct {
+		field     string
+		fieldType qdrant.FieldType
+	}{
+		{field: "doc_id", fieldType: qdrant.FieldType_FieldTypeKeyword},
+		{field: "chunk_index", fieldType: qdrant.FieldType_FieldTypeInteger},
+		{field: "ingested_at", fieldType: qdrant.FieldType_FieldTypeDatetime},
+		{field: "tags", fieldType: qdrant.FieldType_FieldTypeKeyword},
+		{field: "source", fieldType: qdrant.FieldType_FieldTypeKeyword},
+	}
+	for _, idx := range payloadIndexes {
+		_, err := qc.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+			CollectionName: qc.collection,
+			Wait:           &wait,
+			FieldName:      idx.field,
+			FieldType:      idx.fieldType.Enum(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create %s index: %w", idx.field, err)
+		}
+	}
+
 	return nil
 }
 
@@ -66,41 +115,104 @@ func (qc *QdrantClient) UpsertPoints(ctx context.Context, pointsToUpsert []*qdra
 	return nil
 }
 
+// DeletePoints deletes all points matching the filter from the collection
+func (qc *QdrantClient) DeletePoints(ctx context.Context, filter *qdrant.Filter) error {
+	wait := true
+	_, err := qc.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: qc.collection,
+		Wait:           &wait,
+		Points:         qdrant.NewPointsSelectorFilter(filter),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete points: %w", err)
+	}
+	return nil
+}
+
+// ScrollPoints iterates over points matching the filter, returning one page and the next page offset
+func (qc *QdrantClient) ScrollPoints(ctx context.Context, filter *qdrant.Filter, limit uint32, offset *qdrant.PointId) ([]*qdrant.RetrievedPoint, *qdrant.PointId, error) {
+	points, nextOffset, err := qc.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+		CollectionName: qc.collection,
+		Filter:         filter,
+		Offset:         offset,
+		Limit:          &limit,
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scroll points: %w", err)
+	}
+	return points, nextOffset, nil
+}
+
+// CountPoints counts points matching the filter
+func (qc *QdrantClient) CountPoints(ctx context.Context, filter *qdrant.Filter) (uint64, error) {
+	exact := true
+	count, err := qc.client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: qc.collection,
+		Filter:         filter,
+		Exact:          &exact,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	return count, nil
+}
+
+// GetVectors returns the stored vectors of the points with the given IDs, keyed by point ID
+func (qc *QdrantClient) GetVectors(ctx context.Context, ids []*qdrant.PointId) (map[string][]float32, error) {
+	points, err := qc.client.Get(ctx, &qdrant.GetPoints{
+		CollectionName: qc.collection,
+		Ids:            ids,
+		WithPayload:    qdrant.NewWithPayload(false),
+		WithVectors:    qdrant.NewWithVectors(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get points: %w", err)
+	}
+
+	vectors := make(map[string][]float32, len(points))
+	for _, point := range points {
+		vectors[formatPointID(point.GetId())] = point.GetVectors().GetVector().GetDense().GetData()
+	}
+	return vectors, nil
+}
+
 // Search searches for similar vectors in the collection using Qdrant Query API
-func (qc *QdrantClient) Search(ctx context.Context, vector []float32, limit uint64) ([]string, []float32, error) {
+func (qc *QdrantClient) Search(ctx context.Context, vector []float32, limit uint64, opts SearchOptions) ([]SearchResult, error) {
 	// Use Query API for search
 	searchResult, err := qc.client.Query(ctx, &qdrant.QueryPoints{
 		CollectionName: qc.collection,
 		Query:          qdrant.NewQuery(vector...),
 		Limit:          &limit,
 		WithPayload:    qdrant.NewWithPayload(true),
+		WithVectors:    qdrant.NewWithVectors(opts.WithVectors),
+		ScoreThreshold: scoreThreshold(opts.ScoreThreshold),
+		Filter:         opts.Filter,
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to search: %w", err)
+		return nil, fmt.Errorf("failed to search: %w", err)
 	}
 
-	if len(searchResult) == 0 {
-		return []string{}, []float32{}, nil
-	}
-
-	texts := make([]string, 0, len(searchResult))
-	scores := make([]float32, 0, len(searchResult))
+	results := make([]SearchResult, 0, len(searchResult))
 
 	for _, result := range searchResult {
-		// Extract score
-		score := float32(result.Score)
-
-		// Extract text from payload
-		if result.Payload != nil {
-			if textValue, ok := result.Payload["text"]; ok && textValue != nil {
-				// Check if it's a string value
-				if textValue.GetStringValue() != "" {
-					texts = append(texts, textValue.GetStringValue())
-					scores = append(scores, score)
-				}
-			}
+		// Extract text from payload, skipping points without text
+		hit := newSearchResult(result.GetPayload(), result.Score)
+		if hit.Text == "" {
+			continue
 		}
+		hit.Vector = result.GetVectors().GetVector().GetDense().GetData()
+
+		results = append(results, hit)
 	}
 
-	return texts, scores, nil
+	return results, nil
+}
+
+// scoreThreshold converts an optional positive threshold into the Qdrant request field
+func scoreThreshold(threshold float32) *float32 {
+	if threshold <= 0 {
+		return nil
+	}
+	return &threshold
 }
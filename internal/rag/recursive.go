@@ -0,0 +1,343 @@
+package rag
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+// RecursiveChunker splits text at the coarsest boundaries that keep chunks within the chunk
+// size: paragraphs, then lines, then sentences, then words, and characters as a last resort.
+// Chunks are exact slices of the source text, so their layout is preserved, and record their
+// character offsets in it. Size and overlap are measured in characters.
+type RecursiveChunker struct {
+	chunkSize    int
+	chunkOverlap int
+}
+
+// NewRecursiveChunker creates a recursive chunker producing chunks of at most chunkSize characters,
+// with consecutive pieces of a split sharing up to chunkOverlap characters. A non-positive size
+// keeps the text whole.
+func NewRecursiveChunker(chunkSize, chunkOverlap int) *RecursiveChunker {
+	return &RecursiveChunker{
+		chunkSize:    chunkSize,
+		chunkOverlap: chunkOverlap,
+	}
+}
+
+// textSpan is a range of byte offsets in the source text, end exclusive
+type textSpan struct {
+	start, end int
+}
+
+// spanSplitter splits a span of text into consecutive spans covering it
+type spanSplitter func(text string, span textSpan) []textSpan
+
+// recursiveSplitters are the boundaries tried in order, from the coarsest to the finest
+var recursiveSplitters = []spanSplitter{splitParagraphs, splitLines, splitSentences, splitWords}
+
+// lineSplitLevel is the level of splitLines in recursiveSplitters
+const lineSplitLevel = 1
+
+// ChunkText splits text into chunks
+func (c *RecursiveChunker) ChunkText(text string) []string {
+	chunks := c.Chunk(text)
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+	return texts
+}
+
+// Chunk splits text into chunks with their character offsets
+func (c *RecursiveChunker) Chunk(text string) []Chunk {
+	if c.chunkSize <= 0 {
+		return spansToChunks(text, []textSpan{{start: 0, end: len(text)}})
+	}
+	return spansToChunks(text, c.split(text, textSpan{start: 0, end: len(text)}, 0))
+}
+
+// spansToChunks turns consecutive spans of text into chunks trimmed of surrounding whitespace,
+// with their character offsets. Whitespace-only spans are dropped.
+func spansToChunks(text string, spans []textSpan) []Chunk {
+	var chunks []Chunk
+	positions := newTextPositions(text)
+	for _, span := range spans {
+		if chunk, ok := positions.chunk(span); ok {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// textPositions converts byte offsets of a text into character offsets and line numbers.
+// Chunk starts only move forward, so positions are counted incrementally.
+type textPositions struct {
+	text    string
+	bytePos int
+	runePos int
+	// line is the 1-based line at bytePos
+	line int
+}
+
+func newTextPositions(text string) *textPositions {
+	return &textPositions{text: text, line: 1}
+}
+
+// chunk turns a span into a chunk trimmed of surrounding whitespace, with its character offsets,
+// and moves to its start. It reports false for whitespace-only spans.
+func (p *textPositions) chunk(span textSpan) (Chunk, bool) {
+	spanText := p.text[span.start:span.end]
+	return p.chunkFrom(span.start+len(spanText)-len(strings.TrimLeftFunc(spanText, unicode.IsSpace)), span.end)
+}
+
+// chunkLines is like chunk, but only trims leading blank lines, keeping the indentation of the first line
+func (p *textPositions) chunkLines(span textSpan) (Chunk, bool) {
+	spanText := p.text[span.start:span.end]
+	content := len(spanText) - len(strings.TrimLeftFunc(spanText, unicode.IsSpace))
+	return p.chunkFrom(span.start+strings.LastIndexByte(spanText[:content], '\n')+1, span.end)
+}
+
+// chunkFrom turns the text between start and end, trimmed of trailing whitespace, into a chunk
+func (p *textPositions) chunkFrom(start, end int) (Chunk, bool) {
+	trimmed := strings.TrimRightFunc(p.text[start:end], unicode.IsSpace)
+	if strings.TrimSpace(trimmed) == "" {
+		return Chunk{}, false
+	}
+
+	skipped := p.text[p.bytePos:start]
+	p.runePos += utf8.RuneCountInString(skipped)
+	p.line += strings.Count(skipped, "\n")
+	p.bytePos = start
+
+	return Chunk{
+		Text:    trimmed,
+		Offsets: &types.TextRange{Start: p.runePos, End: p.runePos + utf8.RuneCountInString(trimmed)},
+	}, true
+}
+
+// split splits a span with the splitter of the given level. Pieces within the chunk size are
+// merged into chunks; larger pieces are split further at the next level.
+func (c *RecursiveChunker) split(text string, span textSpan, level int) []textSpan {
+	if spanLength(text, span) <= c.chunkSize {
+		return []textSpan{span}
+	}
+	if level == len(recursiveSplitters) {
+		return splitCharacters(text, span, c.chunkSize)
+	}
+
+	var chunks, pending []textSpan
+	for _, piece := range recursiveSplitters[level](text, span) {
+		if spanLength(text, piece) <= c.chunkSize {
+			pending = append(pending, piece)
+			continue
+		}
+		chunks = append(chunks, c.merge(text, pending)...)
+		pending = nil
+		chunks = append(chunks, c.split(text, piece, level+1)...)
+	}
+	return append(chunks, c.merge(text, pending)...)
+}
+
+// merge joins consecutive pieces into chunks of at most chunkSize characters, starting every
+// chunk after the first with the trailing pieces of the previous one that fit into the overlap
+func (c *RecursiveChunker) merge(text string, pieces []textSpan) []textSpan {
+	var chunks []textSpan
+	for start := 0; start < len(pieces); {
+		end, size := start, 0
+		for end < len(pieces) && (end == start || size+spanLength(text, pieces[end]) <= c.chunkSize) {
+			size += spanLength(text, pieces[end])
+			end++
+		}
+		chunks = append(chunks, textSpan{start: pieces[start].start, end: pieces[end-1].end})
+		if end == len(pieces) {
+			break
+		}
+
+		next, overlap := end, 0
+		for next-1 > start && overlap+spanLength(text, pieces[next-1]) <= c.chunkOverlap {
+			overlap += spanLength(text, pieces[next-1])
+			next--
+		}
+		start = next
+	}
+	return chunks
+}
+
+// spanLength returns the length of a span in characters
+func spanLength(text string, span textSpan) int {
+	return utf8.RuneCountInString(text[span.start:span.end])
+}
+
+// splitParagraphs splits a span after every run of blank lines
+func splitParagraphs(text string, span textSpan) []textSpan {
+	var pieces []textSpan
+	start := span.start
+	afterBlank := false
+	for lineStart := span.start; lineStart < span.end; {
+		lineEnd := span.end
+		if i := strings.IndexByte(text[lineStart:span.end], '\n'); i >= 0 {
+			lineEnd = lineStart + i + 1
+		}
+
+		blank := strings.TrimSpace(text[lineStart:lineEnd]) == ""
+		if !blank && afterBlank && lineStart > start {
+			pieces = append(pieces, textSpan{start: start, end: lineStart})
+			start = lineStart
+		}
+		// Blank lines only separate paragraphs once the current one has content
+		afterBlank = blank && strings.TrimSpace(text[start:lineStart]) != ""
+
+		lineStart = lineEnd
+	}
+	return append(pieces, textSpan{start: start, end: span.end})
+}
+
+// splitLines splits a span after every line break
+func splitLines(text string, span textSpan) []textSpan {
+	var pieces []textSpan
+	start := span.start
+	for start < span.end {
+		i := strings.IndexByte(text[start:span.end], '\n')
+		if i < 0 {
+			break
+		}
+		pieces = append(pieces, textSpan{start: start, end: start + i + 1})
+		start += i + 1
+	}
+	if start < span.end {
+		pieces = append(pieces, textSpan{start: start, end: span.end})
+	}
+	return pieces
+}
+
+// splitWords splits a span after every run of whitespace
+func splitWords(text string, span textSpan) []textSpan {
+	var pieces []textSpan
+	start := span.start
+	inWord := false
+	for i := span.start; i < span.end; {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		space := unicode.IsSpace(r)
+		if !space && !inWord && strings.TrimSpace(text[start:i]) != "" {
+			pieces = append(pieces, textSpan{start: start, end: i})
+			start = i
+		}
+		inWord = !space
+		i += size
+	}
+	return append(pieces, textSpan{start: start, end: span.end})
+}
+
+// splitCharacters cuts a span into pieces of at most size characters
+func splitCharacters(text string, span textSpan, size int) []textSpan {
+	var pieces []textSpan
+	start, count := span.start, 0
+	for i := span.start; i < span.end; {
+		if count == size {
+			pieces = append(pieces, textSpan{start: start, end: i})
+			start, count = i, 0
+		}
+		_, runeSize := utf8.DecodeRuneInString(text[i:])
+		i += runeSize
+		count++
+	}
+	return append(pieces, textSpan{start: start, end: span.end})
+}
+
+// sentenceTerminators end a sentence when followed by whitespace
+const sentenceTerminators = ".!?…"
+
+// sentenceClosers may follow a sentence terminator and belong to the sentence
+const sentenceClosers = "\"')]»”’"
+
+// nonTerminalAbbreviations are abbreviations that are usually followed by a name, a number or
+// another capitalised word rather than ending the sentence. Single letters, such as initials
+// or the parts of "т. е.", are always treated as abbreviations.
+var nonTerminalAbbreviations = map[string]bool{
+	// English
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true,
+	"st": true, "mt": true, "vs": true, "e.g": true, "i.e": true, "cf": true, "viz": true,
+	"fig": true, "figs": true, "vol": true, "ch": true, "sec": true, "pp": true, "approx": true,
+	"dept": true, "jan": true, "feb": true, "mar": true, "apr": true, "jun": true, "jul": true,
+	"aug": true, "sep": true, "sept": true, "oct": true, "nov": true, "dec": true,
+	// Russian
+	"т.е": true, "т.к": true, "т.н": true, "т.ч": true, "гг": true, "вв": true, "ул": true,
+	"пр": true, "рис": true, "стр": true, "им": true, "проф": true, "акад": true, "доц": true,
+	"см": true, "ср": true, "напр": true, "обл": true, "пос": true, "св": true, "ок": true,
+}
+
+// splitSentences splits a span after the whitespace that follows the end of every sentence
+func splitSentences(text string, span textSpan) []textSpan {
+	var pieces []textSpan
+	start := span.start
+	for i := span.start; i < span.end; {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !strings.ContainsRune(sentenceTerminators, r) {
+			i += size
+			continue
+		}
+
+		terminator := i
+		end := i + size
+		for end < span.end {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !strings.ContainsRune(sentenceTerminators, next) && !strings.ContainsRune(sentenceClosers, next) {
+				break
+			}
+			end += nextSize
+		}
+
+		following := end
+		for following < span.end {
+			next, nextSize := utf8.DecodeRuneInString(text[following:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			following += nextSize
+		}
+
+		if following > end && following < span.end && isSentenceBoundary(text, terminator, end, following) {
+			pieces = append(pieces, textSpan{start: start, end: following})
+			start = following
+		}
+		i = following
+	}
+	return append(pieces, textSpan{start: start, end: span.end})
+}
+
+// isSentenceBoundary reports whether the terminator at byte offset terminator, whose
+// punctuation ends at end, finishes a sentence followed by the one starting at next
+func isSentenceBoundary(text string, terminator, end, next int) bool {
+	nextRune, _ := utf8.DecodeRuneInString(text[next:])
+	if unicode.IsLower(nextRune) {
+		return false
+	}
+
+	// Only a single period can belong to an abbreviation
+	punctuation := text[terminator:end]
+	if !strings.HasPrefix(punctuation, ".") || strings.HasPrefix(punctuation, "..") {
+		return true
+	}
+
+	word := lastWord(text[:terminator])
+	if utf8.RuneCountInString(word) == 1 {
+		return false
+	}
+	return !nonTerminalAbbreviations[strings.ToLower(word)]
+}
+
+// lastWord returns the letters and inner periods at the end of text, e.g. "e.g" of "see e.g"
+func lastWord(text string) string {
+	start := len(text)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !unicode.IsLetter(r) && r != '.' {
+			break
+		}
+		start -= size
+	}
+	return strings.Trim(text[start:], ".")
+}
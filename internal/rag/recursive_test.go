@@ -0,0 +1,184 @@
+package rag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/types"
+)
+
+func TestRecursiveChunker_Chunk(t *testing.T) {
+	tests := []struct {
+		name         string
+		chunkSize    int
+		chunkOverlap int
+		text         string
+		want         []string
+		wantOffsets  []types.TextRange
+	}{
+		{
+			name:      "empty text",
+			chunkSize: 100,
+			text:      " \n\n ",
+			want:      nil,
+		},
+		{
+			name:        "text smaller than chunk size",
+			chunkSize:   100,
+			text:        "  Hello world.\n",
+			want:        []string{"Hello world."},
+			wantOffsets: []types.TextRange{{Start: 2, End: 14}},
+		},
+		{
+			name:        "paragraphs",
+			chunkSize:   20,
+			text:        "First paragraph.\n\nSecond paragraph.",
+			want:        []string{"First paragraph.", "Second paragraph."},
+			wantOffsets: []types.TextRange{{Start: 0, End: 16}, {Start: 18, End: 35}},
+		},
+		{
+			name:        "layout is preserved",
+			chunkSize:   25,
+			text:        "alpha beta\n  gamma delta\n\nnext",
+			want:        []string{"alpha beta\n  gamma delta", "next"},
+			wantOffsets: []types.TextRange{{Start: 0, End: 24}, {Start: 26, End: 30}},
+		},
+		{
+			name:      "english sentences with abbreviations",
+			chunkSize: 30,
+			text:      "Dr. Smith met Mr. Jones. They talked about e.g. Go. Then they left.",
+			want:      []string{"Dr. Smith met Mr. Jones.", "They talked about e.g. Go.", "Then they left."},
+			wantOffsets: []types.TextRange{
+				{Start: 0, End: 24}, {Start: 25, End: 51}, {Start: 52, End: 67},
+			},
+		},
+		{
+			name:        "russian sentences with abbreviations and initials",
+			chunkSize:   35,
+			text:        "См. рис. 5, т. е. схему сети. Её описал А. С. Пушкин. Конец!",
+			want:        []string{"См. рис. 5, т. е. схему сети.", "Её описал А. С. Пушкин. Конец!"},
+			wantOffsets: []types.TextRange{{Start: 0, End: 29}, {Start: 30, End: 60}},
+		},
+		{
+			name:         "words with overlap",
+			chunkSize:    14,
+			chunkOverlap: 6,
+			text:         "one two three four five six",
+			want:         []string{"one two three", "three four", "four five six"},
+			wantOffsets:  []types.TextRange{{Start: 0, End: 13}, {Start: 8, End: 18}, {Start: 14, End: 27}},
+		},
+		{
+			name:        "word longer than chunk size",
+			chunkSize:   3,
+			text:        "абвгдеж",
+			want:        []string{"абв", "где", "ж"},
+			wantOffsets: []types.TextRange{{Start: 0, End: 3}, {Start: 3, End: 6}, {Start: 6, End: 7}},
+		},
+		{
+			name:        "non-positive chunk size keeps text whole",
+			chunkSize:   0,
+			text:        "\nFirst.\n\nSecond.\n",
+			want:        []string{"First.\n\nSecond."},
+			wantOffsets: []types.TextRange{{Start: 1, End: 16}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := NewRecursiveChunker(tt.chunkSize, tt.chunkOverlap).Chunk(tt.text)
+
+			var got []string
+			var gotOffsets []types.TextRange
+			for _, chunk := range chunks {
+				got = append(got, chunk.Text)
+				gotOffsets = append(gotOffsets, *chunk.Offsets)
+
+				if source := string([]rune(tt.text)[chunk.Offsets.Start:chunk.Offsets.End]); source != chunk.Text {
+					t.Errorf("chunk %q has offsets %+v pointing at %q", chunk.Text, *chunk.Offsets, source)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunk() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotOffsets, tt.wantOffsets) {
+				t.Errorf("Chunk() offsets = %+v, want %+v", gotOffsets, tt.wantOffsets)
+			}
+		})
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "question in quotes",
+			text: `He asked: "Why?" Then he left.`,
+			want: []string{`He asked: "Why?" `, "Then he left."},
+		},
+		{
+			name: "ellipsis",
+			text: "Wait... What?",
+			want: []string{"Wait... ", "What?"},
+		},
+		{
+			name: "decimal numbers and lower-case continuation",
+			text: "Version 1.2 is out. version 2 is not.",
+			want: []string{"Version 1.2 is out. version 2 is not."},
+		},
+		{
+			name: "russian units",
+			text: "Цена 5 тыс. руб. Скидка 10%.",
+			want: []string{"Цена 5 тыс. руб. ", "Скидка 10%."},
+		},
+		{
+			name: "russian dialogue",
+			text: "Он ушёл.\n— Куда?",
+			want: []string{"Он ушёл.\n", "— Куда?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, span := range splitSentences(tt.text, textSpan{start: 0, end: len(tt.text)}) {
+				got = append(got, tt.text[span.start:span.end])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_ChunkOffsets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"First paragraph.", "Second paragraph."}).
+		Return([][]float32{{1, 0}, {0, 1}}, nil)
+	mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "second").Return([]float32{0, 1}, nil)
+
+	ctx := context.Background()
+	pipeline, err := NewPipeline(NewRecursiveChunker(20, 0), mockLLM, newTestMemoryStore(t, ""), 2, 1)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+
+	if err := pipeline.Ingest(ctx, "First paragraph.\n\nSecond paragraph.", "doc", nil); err != nil {
+		t.Fatalf("Ingest() unexpected error: %v", err)
+	}
+
+	results, err := pipeline.Retrieve(ctx, "second", RetrieveOptions{})
+	if err != nil {
+		t.Fatalf("Retrieve() unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Offsets == nil || *results[0].Offsets != (types.TextRange{Start: 18, End: 35}) {
+		t.Fatalf("Retrieve() = %+v, want the second paragraph with its offsets", results)
+	}
+}
@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+//go:generate mockgen -source=rerank.go -destination=mock_reranker.go -package=rag -self_package=github.com/vokinneberg/ya-practicum-go-and-llm/internal/rag Reranker
+
+// Reranker reorders retrieved candidates by relevance to the query
+type Reranker interface {
+	// Rerank returns the candidates sorted by descending relevance, with Score set to the reranker score
+	Rerank(ctx context.Context, query string, candidates []SearchResult) ([]SearchResult, error)
+}
+
+// RelevanceScorer rates how well each passage answers a question
+type RelevanceScorer interface {
+	ScoreRelevance(ctx context.Context, question string, passages []string) ([]float64, error)
+}
+
+// LLMReranker reranks candidates with relevance scores produced by a chat model
+type LLMReranker struct {
+	scorer RelevanceScorer
+}
+
+// NewLLMReranker creates a reranker backed by an LLM relevance scorer
+func NewLLMReranker(scorer RelevanceScorer) *LLMReranker {
+	return &LLMReranker{scorer: scorer}
+}
+
+// Rerank scores all candidates in a single LLM request and sorts them by score
+func (r *LLMReranker) Rerank(ctx context.Context, query string, candidates []SearchResult) ([]SearchResult, error) {
+	passages := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		passages[i] = candidate.Text
+	}
+
+	scores, err := r.scorer.ScoreRelevance(ctx, query, passages)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortByScores(candidates, scores)
+}
+
+// httpRerankerTimeout bounds a single request to the rerank endpoint
+const httpRerankerTimeout = 30 * time.Second
+
+// HTTPReranker reranks candidates with a cross-encoder served over HTTP.
+// It speaks the Cohere/Jina style rerank API, also implemented by llama.cpp and Infinity:
+// POST {"model", "query", "documents"} returning {"results": [{"index", "relevance_score"}]}.
+type HTTPReranker struct {
+	url        string
+	model      string
+	httpClient *http.Client
+}
+
+// NewHTTPReranker creates a reranker calling the rerank endpoint at url; model may be empty
+func NewHTTPReranker(url, model string) *HTTPReranker {
+	return &HTTPReranker{
+		url:        url,
+		model:      model,
+		httpClient: &http.Client{Timeout: httpRerankerTimeout},
+	}
+}
+
+// httpRerankRequest is the request body of the rerank endpoint
+type httpRerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+}
+
+// httpRerankResponse is the response body of the rerank endpoint
+type httpRerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// Rerank sends all candidates to the rerank endpoint and sorts them by the returned scores
+func (r *HTTPReranker) Rerank(ctx context.Context, query string, candidates []SearchResult) ([]SearchResult, error) {
+	documents := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		documents[i] = candidate.Text
+	}
+
+	body, err := json.Marshal(httpRerankRequest{Model: r.model, Query: query, Documents: documents})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rerank request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rerank request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call rerank endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank endpoint returned status %d", resp.StatusCode)
+	}
+
+	var rerankResp httpRerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to decode rerank response: %w", err)
+	}
+
+	// Results may be sorted by score or truncated to the top documents; unscored candidates are dropped
+	reranked := make([]SearchResult, 0, len(rerankResp.Results))
+	for _, result := range rerankResp.Results {
+		if result.Index < 0 || result.Index >= len(candidates) {
+			return nil, fmt.Errorf("rerank endpoint returned out of range index %d", result.Index)
+		}
+		candidate := candidates[result.Index]
+		candidate.Score = float32(result.RelevanceScore)
+		reranked = append(reranked, candidate)
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+
+	return reranked, nil
+}
+
+// sortByScores returns a copy of candidates with the given scores, sorted by descending score.
+// Ties keep the original retrieval order.
+func sortByScores(candidates []SearchResult, scores []float64) ([]SearchResult, error) {
+	if len(scores) != len(candidates) {
+		return nil, fmt.Errorf("got %d scores for %d candidates", len(scores), len(candidates))
+	}
+
+	reranked := make([]SearchResult, len(candidates))
+	for i, candidate := range candidates {
+		candidate.Score = float32(scores[i])
+		reranked[i] = candidate
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+
+	return reranked, nil
+}
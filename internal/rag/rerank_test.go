@@ -0,0 +1,173 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+// scorerFunc adapts a function to the RelevanceScorer interface
+type scorerFunc func(ctx context.Context, question string, passages []string) ([]float64, error)
+
+func (f scorerFunc) ScoreRelevance(ctx context.Context, question string, passages []string) ([]float64, error) {
+	return f(ctx, question, passages)
+}
+
+func TestLLMReranker_Rerank(t *testing.T) {
+	candidates := []SearchResult{
+		{Text: "near duplicate", DocID: "a", ChunkIndex: 0, Score: 0.9},
+		{Text: "near duplicate again", DocID: "a", ChunkIndex: 1, Score: 0.88},
+		{Text: "the actual answer", DocID: "b", ChunkIndex: 5, Score: 0.7},
+	}
+
+	reranker := NewLLMReranker(scorerFunc(func(ctx context.Context, question string, passages []string) ([]float64, error) {
+		if question != "query" || len(passages) != 3 || passages[2] != "the actual answer" {
+			t.Errorf("ScoreRelevance() called with %q, %q", question, passages)
+		}
+		return []float64{4, 4, 9}, nil
+	}))
+
+	got, err := reranker.Rerank(context.Background(), "query", candidates)
+	if err != nil {
+		t.Fatalf("Rerank() unexpected error: %v", err)
+	}
+
+	if len(got) != 3 || got[0].DocID != "b" || got[0].Score != 9 {
+		t.Fatalf("Rerank() = %+v, want the actual answer first", got)
+	}
+	if got[1].ChunkIndex != 0 || got[2].ChunkIndex != 1 {
+		t.Errorf("Rerank() = %+v, want ties in retrieval order", got)
+	}
+
+	failing := NewLLMReranker(scorerFunc(func(context.Context, string, []string) ([]float64, error) {
+		return nil, errors.New("API error")
+	}))
+	if _, err := failing.Rerank(context.Background(), "query", candidates); err == nil {
+		t.Error("Rerank() expected error but got nil")
+	}
+}
+
+func TestHTTPReranker_Rerank(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req httpRerankRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Query != "query" || req.Model != "bge-reranker" || len(req.Documents) != 3 {
+			t.Errorf("request = %+v, want query, model and 3 documents", req)
+		}
+
+		// Top 2 only, sorted by score
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"results": []map[string]any{
+				{"index": 2, "relevance_score": 0.97},
+				{"index": 0, "relevance_score": 0.12},
+			},
+		})
+	}))
+	defer server.Close()
+
+	candidates := []SearchResult{
+		{Text: "first", DocID: "a"},
+		{Text: "second", DocID: "b"},
+		{Text: "third", DocID: "c"},
+	}
+
+	reranker := NewHTTPReranker(server.URL+"/v1/rerank", "bge-reranker")
+	got, err := reranker.Rerank(context.Background(), "query", candidates)
+	if err != nil {
+		t.Fatalf("Rerank() unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0].DocID != "c" || got[1].DocID != "a" {
+		t.Errorf("Rerank() = %+v, want [c a]", got)
+	}
+}
+
+func TestPipeline_Retrieve_Rerank(t *testing.T) {
+	tests := []struct {
+		name        string
+		setupMocks  func(*MockReranker)
+		wantDocIDs  []string
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "keeps the best search limit candidates",
+			setupMocks: func(r *MockReranker) {
+				r.EXPECT().Rerank(gomock.Any(), "query", gomock.Len(5)).DoAndReturn(
+					func(ctx context.Context, query string, candidates []SearchResult) ([]SearchResult, error) {
+						return []SearchResult{candidates[4], candidates[0], candidates[2], candidates[1], candidates[3]}, nil
+					},
+				)
+			},
+			wantDocIDs: []string{"d4", "d0"},
+		},
+		{
+			name: "reranker fails",
+			setupMocks: func(r *MockReranker) {
+				r.EXPECT().Rerank(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("timeout"))
+			},
+			wantErr:     true,
+			errContains: "failed to rerank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLLM := NewMockLLMClient(ctrl)
+			mockDB := NewMockVectorDatabase(ctrl)
+			mockReranker := NewMockReranker(ctrl)
+
+			candidates := make([]SearchResult, 5)
+			for i := range candidates {
+				candidates[i] = SearchResult{Text: "chunk", DocID: fmt.Sprintf("d%d", i)}
+			}
+
+			mockDB.EXPECT().EnsureCollection(gomock.Any(), uint64(2)).Return(nil)
+			mockLLM.EXPECT().GenerateEmbedding(gomock.Any(), "query").Return([]float32{1, 0}, nil)
+			mockDB.EXPECT().Search(gomock.Any(), []float32{1, 0}, uint64(5), SearchOptions{}).Return(candidates, nil)
+			tt.setupMocks(mockReranker)
+
+			pipeline, err := NewPipeline(NewChunker(100, 0), mockLLM, mockDB, 2, 2, WithReranker(mockReranker, 5))
+			if err != nil {
+				t.Fatalf("NewPipeline() unexpected error: %v", err)
+			}
+
+			got, err := pipeline.Retrieve(context.Background(), "query", RetrieveOptions{})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Retrieve() expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Retrieve() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Retrieve() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.wantDocIDs) {
+				t.Fatalf("Retrieve() = %+v, want %v", got, tt.wantDocIDs)
+			}
+			for i, docID := range tt.wantDocIDs {
+				if got[i].DocID != docID {
+					t.Errorf("Retrieve()[%d] = %q, want %q", i, got[i].DocID, docID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+)
+
+// semanticWindow is the number of neighbouring sentences on each side embedded together with
+// a sentence, which smooths out the similarity of short sentences
+const semanticWindow = 1
+
+// SemanticChunker splits text where the topic changes. It embeds consecutive sentences and
+// starts a new chunk between two sentences whose similarity is below the given percentile of all
+// adjacent similarities in the document, as long as the chunk has reached the minimum size.
+// Chunks never exceed the maximum size. Sizes are measured in characters.
+type SemanticChunker struct {
+	llmClient  LLMClient
+	minSize    int
+	maxSize    int
+	percentile float64
+}
+
+// NewSemanticChunker creates a semantic chunker. percentile is between 0 and 100: higher values
+// split more often. A non-positive maxSize does not bound the chunk size.
+func NewSemanticChunker(llmClient LLMClient, minSize, maxSize int, percentile float64) *SemanticChunker {
+	return &SemanticChunker{
+		llmClient:  llmClient,
+		minSize:    minSize,
+		maxSize:    maxSize,
+		percentile: percentile,
+	}
+}
+
+// ChunkText splits text into chunks. If the sentences cannot be embedded, the error is logged
+// and chunks are bounded by the maximum size only.
+func (c *SemanticChunker) ChunkText(text string) []string {
+	chunks, err := c.ChunkContext(context.Background(), text)
+	if err != nil {
+		slog.Warn("Failed to embed sentences, chunking by size only", "error", err)
+		chunks = spansToChunks(text, c.pack(text, c.sentences(text), nil, 0))
+	}
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+	return texts
+}
+
+// ChunkContext splits text into chunks with their character offsets
+func (c *SemanticChunker) ChunkContext(ctx context.Context, text string) ([]Chunk, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+
+	sentences := c.sentences(text)
+	if len(sentences) < 2 {
+		return spansToChunks(text, sentences), nil
+	}
+
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		first := max(i-semanticWindow, 0)
+		last := min(i+semanticWindow, len(sentences)-1)
+		windows[i] = text[sentences[first].start:sentences[last].end]
+	}
+	embeddings, err := c.llmClient.GenerateEmbeddings(ctx, windows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sentences: %w", err)
+	}
+	if len(embeddings) != len(sentences) {
+		return nil, fmt.Errorf("failed to embed sentences: got %d embeddings for %d sentences", len(embeddings), len(sentences))
+	}
+
+	// similarities[i] is the similarity of sentences i and i+1
+	similarities := make([]float64, len(sentences)-1)
+	previous := normalize(embeddings[0])
+	for i := range similarities {
+		next := normalize(embeddings[i+1])
+		similarities[i] = float64(dot(previous, next))
+		previous = next
+	}
+
+	return spansToChunks(text, c.pack(text, sentences, similarities, percentile(similarities, c.percentile))), nil
+}
+
+// sentences splits text into paragraphs and paragraphs into sentences. Sentences longer than
+// the maximum size are split further at lines, then words.
+func (c *SemanticChunker) sentences(text string) []textSpan {
+	splitter := NewRecursiveChunker(c.maxSize, 0)
+
+	var sentences []textSpan
+	for _, paragraph := range splitParagraphs(text, textSpan{start: 0, end: len(text)}) {
+		for _, sentence := range splitSentences(text, paragraph) {
+			if c.maxSize > 0 && spanLength(text, sentence) > c.maxSize {
+				sentences = append(sentences, splitter.split(text, sentence, 0)...)
+				continue
+			}
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
+
+// pack joins consecutive sentences into chunks, starting a new chunk where the similarity to the
+// previous sentence is below threshold and the chunk has the minimum size, or where the next
+// sentence would exceed the maximum size. Without similarities, only the maximum size applies.
+func (c *SemanticChunker) pack(text string, sentences []textSpan, similarities []float64, threshold float64) []textSpan {
+	if len(sentences) == 0 {
+		return nil
+	}
+
+	var chunks []textSpan
+	current := sentences[0]
+	size := spanLength(text, current)
+	for i, sentence := range sentences[1:] {
+		sentenceSize := spanLength(text, sentence)
+		topicChange := similarities != nil && similarities[i] < threshold && size >= c.minSize
+		if topicChange || (c.maxSize > 0 && size+sentenceSize > c.maxSize) {
+			chunks = append(chunks, current)
+			current, size = sentence, sentenceSize
+			continue
+		}
+		current.end = sentence.end
+		size += sentenceSize
+	}
+	return append(chunks, current)
+}
+
+// percentile returns the p-th percentile of values, interpolating linearly between the closest ranks
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return math.Inf(-1)
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
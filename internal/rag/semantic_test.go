@@ -0,0 +1,173 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+// topicText has three sentences about pods followed by three about services
+const topicText = "Pods are the smallest deployable units. A pod runs one or more containers. " +
+	"Pods share storage and network. Services expose applications on the network. " +
+	"A service has a stable IP. Services balance traffic across endpoints."
+
+// topicEmbeddings embeds texts by counting mentions of pods and services
+func topicEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		text = strings.ToLower(text)
+		embeddings[i] = []float32{float32(strings.Count(text, "pod")), float32(strings.Count(text, "service"))}
+	}
+	return embeddings, nil
+}
+
+func TestSemanticChunker_ChunkContext(t *testing.T) {
+	tests := []struct {
+		name       string
+		minSize    int
+		maxSize    int
+		percentile float64
+		text       string
+		want       []string
+	}{
+		{
+			name:       "splits where the topic changes",
+			maxSize:    1000,
+			percentile: 10,
+			text:       topicText,
+			want: []string{
+				"Pods are the smallest deployable units. A pod runs one or more containers. Pods share storage and network.",
+				"Services expose applications on the network. A service has a stable IP. Services balance traffic across endpoints.",
+			},
+		},
+		{
+			name:       "minimum size keeps the topics together",
+			minSize:    200,
+			maxSize:    1000,
+			percentile: 10,
+			text:       topicText,
+			want:       []string{topicText},
+		},
+		{
+			name:       "maximum size splits within a topic",
+			maxSize:    80,
+			percentile: 10,
+			text:       topicText,
+			want: []string{
+				"Pods are the smallest deployable units. A pod runs one or more containers.",
+				"Pods share storage and network.",
+				"Services expose applications on the network. A service has a stable IP.",
+				"Services balance traffic across endpoints.",
+			},
+		},
+		{
+			name:       "single sentence is not embedded",
+			maxSize:    1000,
+			percentile: 10,
+			text:       "Pods are the smallest deployable units.",
+			want:       []string{"Pods are the smallest deployable units."},
+		},
+		{
+			name:       "empty text",
+			maxSize:    1000,
+			percentile: 10,
+			text:       "  ",
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLLM := NewMockLLMClient(ctrl)
+			mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(topicEmbeddings).AnyTimes()
+
+			chunks, err := NewSemanticChunker(mockLLM, tt.minSize, tt.maxSize, tt.percentile).ChunkContext(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("ChunkContext() unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, chunk := range chunks {
+				got = append(got, chunk.Text)
+				if source := string([]rune(tt.text)[chunk.Offsets.Start:chunk.Offsets.End]); source != chunk.Text {
+					t.Errorf("chunk %q has offsets %+v pointing at %q", chunk.Text, *chunk.Offsets, source)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChunkContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSemanticChunker_EmbeddingWindows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), []string{"One. Two. ", "One. Two. Three.", "Two. Three."}).
+		Return([][]float32{{1, 0}, {1, 0}, {1, 0}}, nil)
+
+	if _, err := NewSemanticChunker(mockLLM, 0, 100, 10).ChunkContext(context.Background(), "One. Two. Three."); err != nil {
+		t.Fatalf("ChunkContext() unexpected error: %v", err)
+	}
+}
+
+func TestSemanticChunker_EmbeddingError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	embedErr := errors.New("rate limited")
+	mockLLM := NewMockLLMClient(ctrl)
+	mockLLM.EXPECT().GenerateEmbeddings(gomock.Any(), gomock.Any()).Return(nil, embedErr).Times(3)
+
+	chunker := NewSemanticChunker(mockLLM, 0, 80, 10)
+	if _, err := chunker.ChunkContext(context.Background(), topicText); !errors.Is(err, embedErr) {
+		t.Errorf("ChunkContext() error = %v, want %v", err, embedErr)
+	}
+
+	// ChunkText falls back to the maximum size
+	got := chunker.ChunkText(topicText)
+	for _, chunk := range got {
+		if len([]rune(chunk)) > 80 {
+			t.Errorf("ChunkText() chunk %q is longer than the maximum size", chunk)
+		}
+	}
+	if len(got) < 2 {
+		t.Errorf("ChunkText() = %q, want several chunks", got)
+	}
+
+	pipeline, err := NewPipeline(chunker, mockLLM, newTestMemoryStore(t, ""), 2, 1)
+	if err != nil {
+		t.Fatalf("NewPipeline() unexpected error: %v", err)
+	}
+	if err := pipeline.Ingest(context.Background(), topicText, "doc", nil); !errors.Is(err, embedErr) {
+		t.Errorf("Ingest() error = %v, want %v", err, embedErr)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{0.9, 0.1, 0.5, 0.7, 0.3}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{p: 0, want: 0.1},
+		{p: 50, want: 0.5},
+		{p: 100, want: 0.9},
+		{p: 10, want: 0.18},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(values, tt.p); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package rag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer converts text into the tokens of a language model
+type Tokenizer interface {
+	// Split splits text into the pieces tokens are formed from; tokens never cross piece boundaries
+	Split(text string) []string
+	Encode(text string) []int
+	Decode(tokens []int) string
+}
+
+// TokenChunker splits text into chunks measured in model tokens, so that chunk size and
+// overlap mean the same for every language. Chunks end at tokenizer piece boundaries,
+// which are word boundaries for ordinary text.
+type TokenChunker struct {
+	tokenizer    Tokenizer
+	chunkSize    int
+	chunkOverlap int
+}
+
+// NewTokenChunker creates a chunker producing chunks of at most chunkSize tokens, with
+// consecutive chunks sharing up to chunkOverlap tokens. Sizes are counted before whitespace
+// around the chunk is trimmed. A non-positive size keeps the text whole.
+func NewTokenChunker(tokenizer Tokenizer, chunkSize, chunkOverlap int) *TokenChunker {
+	return &TokenChunker{
+		tokenizer:    tokenizer,
+		chunkSize:    chunkSize,
+		chunkOverlap: chunkOverlap,
+	}
+}
+
+// tokenSpan is a run of text together with its length in tokens
+type tokenSpan struct {
+	text   string
+	tokens int
+}
+
+// ChunkText splits text into chunks of at most chunkSize tokens with overlap
+func (c *TokenChunker) ChunkText(text string) []string {
+	chunks := []string{}
+	if strings.TrimSpace(text) == "" {
+		return chunks
+	}
+	if c.chunkSize <= 0 {
+		return append(chunks, strings.TrimSpace(text))
+	}
+
+	spans := c.spans(text)
+	for start := 0; start < len(spans); {
+		end, size := start, 0
+		// A span longer than the chunk size still makes up a chunk of its own
+		for end < len(spans) && (end == start || size+spans[end].tokens <= c.chunkSize) {
+			size += spans[end].tokens
+			end++
+		}
+
+		var sb strings.Builder
+		for _, span := range spans[start:end] {
+			sb.WriteString(span.text)
+		}
+		if chunk := strings.TrimSpace(sb.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(spans) {
+			break
+		}
+
+		// Step back over the trailing spans that fit into the overlap, always moving forward
+		next, overlap := end, 0
+		for next-1 > start && overlap+spans[next-1].tokens <= c.chunkOverlap {
+			overlap += spans[next-1].tokens
+			next--
+		}
+		start = next
+	}
+
+	return chunks
+}
+
+// spans splits text into tokenizer pieces, breaking pieces longer than the chunk size
+// into runs of at most chunkSize tokens
+func (c *TokenChunker) spans(text string) []tokenSpan {
+	var spans []tokenSpan
+	for _, piece := range c.tokenizer.Split(text) {
+		tokens := c.tokenizer.Encode(piece)
+		if len(tokens) <= c.chunkSize {
+			spans = append(spans, tokenSpan{text: piece, tokens: len(tokens)})
+			continue
+		}
+		spans = append(spans, c.splitTokens(tokens)...)
+	}
+	return spans
+}
+
+// splitTokens splits tokens into runs of at most chunkSize tokens. Runs are shortened so
+// that they do not cut a multi-byte character, or extended if a single character spans
+// more tokens than the chunk size.
+func (c *TokenChunker) splitTokens(tokens []int) []tokenSpan {
+	var spans []tokenSpan
+	for start := 0; start < len(tokens); {
+		end := min(start+c.chunkSize, len(tokens))
+		for end > start+1 && !utf8.ValidString(c.tokenizer.Decode(tokens[start:end])) {
+			end--
+		}
+		for end < len(tokens) && !utf8.ValidString(c.tokenizer.Decode(tokens[start:end])) {
+			end++
+		}
+		spans = append(spans, tokenSpan{text: c.tokenizer.Decode(tokens[start:end]), tokens: end - start})
+		start = end
+	}
+	return spans
+}
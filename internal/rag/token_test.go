@@ -0,0 +1,162 @@
+package rag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/vokinneberg/ya-practicum-go-and-llm/internal/tokenizer"
+)
+
+// newTestEncoding returns a cl100k-style encoding with single-byte tokens, the listed
+// words as tokens and, with cyrillicLetters, every lower-case Cyrillic letter as one token
+func newTestEncoding(t *testing.T, cyrillicLetters bool, words ...string) *tokenizer.Encoding {
+	t.Helper()
+
+	ranks := make(map[string]int)
+	for b := 0; b < 256; b++ {
+		ranks[string([]byte{byte(b)})] = len(ranks)
+	}
+	if cyrillicLetters {
+		for r := 'а'; r <= 'я'; r++ {
+			ranks[string(r)] = len(ranks)
+		}
+	}
+	for _, word := range words {
+		ranks[word] = len(ranks)
+	}
+
+	encoding, err := tokenizer.NewEncoding(tokenizer.CL100KBase, ranks)
+	if err != nil {
+		t.Fatalf("NewEncoding() error = %v", err)
+	}
+	return encoding
+}
+
+func TestTokenChunker_ChunkText(t *testing.T) {
+	english := newTestEncoding(t, false, "one", " two", " three", " four", " five", " six")
+	russian := newTestEncoding(t, true)
+	bytesOnly := newTestEncoding(t, false)
+
+	tests := []struct {
+		name         string
+		encoding     *tokenizer.Encoding
+		chunkSize    int
+		chunkOverlap int
+		text         string
+		want         []string
+	}{
+		{
+			name:      "empty text",
+			encoding:  english,
+			chunkSize: 3,
+			text:      "  \n ",
+			want:      []string{},
+		},
+		{
+			name:      "text smaller than chunk size",
+			encoding:  english,
+			chunkSize: 10,
+			text:      "one two three",
+			want:      []string{"one two three"},
+		},
+		{
+			name:      "english without overlap",
+			encoding:  english,
+			chunkSize: 3,
+			text:      "one two three four five six",
+			want:      []string{"one two three", "four five six"},
+		},
+		{
+			name:         "english with overlap",
+			encoding:     english,
+			chunkSize:    3,
+			chunkOverlap: 1,
+			text:         "one two three four five six",
+			want:         []string{"one two three", "three four five", "five six"},
+		},
+		{
+			// " дом" is a space token and three letter tokens
+			name:      "russian without overlap",
+			encoding:  russian,
+			chunkSize: 8,
+			text:      "мир дом кот",
+			want:      []string{"мир дом", "кот"},
+		},
+		{
+			name:         "russian with overlap",
+			encoding:     russian,
+			chunkSize:    8,
+			chunkOverlap: 4,
+			text:         "мир дом кот",
+			want:         []string{"мир дом", "дом кот"},
+		},
+		{
+			name:      "word longer than chunk size",
+			encoding:  russian,
+			chunkSize: 4,
+			text:      "мирмирмир",
+			want:      []string{"мирм", "ирми", "р"},
+		},
+		{
+			name:      "multi-byte characters are not cut",
+			encoding:  bytesOnly,
+			chunkSize: 3,
+			text:      "мир",
+			want:      []string{"м", "и", "р"},
+		},
+		{
+			name:         "overlap not smaller than chunk size still progresses",
+			encoding:     english,
+			chunkSize:    2,
+			chunkOverlap: 5,
+			text:         "one two three four",
+			want:         []string{"one two", "two three", "three four"},
+		},
+		{
+			name:      "non-positive chunk size keeps text whole",
+			encoding:  english,
+			chunkSize: 0,
+			text:      " one two three ",
+			want:      []string{"one two three"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunker := NewTokenChunker(tt.encoding, tt.chunkSize, tt.chunkOverlap)
+			got := chunker.ChunkText(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChunkText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenChunker_ChunkSizeInTokens(t *testing.T) {
+	encoding := newTestEncoding(t, true)
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20) +
+		strings.Repeat("Съешь же ещё этих мягких французских булок, да выпей чаю. ", 20)
+
+	const chunkSize = 32
+	chunks := NewTokenChunker(encoding, chunkSize, 8).ChunkText(text)
+	if len(chunks) < 2 {
+		t.Fatalf("ChunkText() returned %d chunks, want several", len(chunks))
+	}
+
+	for i, chunk := range chunks {
+		if !utf8.ValidString(chunk) {
+			t.Errorf("chunk %d is not valid UTF-8: %q", i, chunk)
+		}
+		if count := encoding.Count(chunk); count > chunkSize {
+			t.Errorf("chunk %d has %d tokens, want at most %d: %q", i, count, chunkSize, chunk)
+		}
+	}
+	if !strings.HasPrefix(chunks[0], "The quick") {
+		t.Errorf("first chunk = %q, want it to start the text", chunks[0])
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasSuffix(last, "выпей чаю.") {
+		t.Errorf("last chunk = %q, want it to end the text", last)
+	}
+}
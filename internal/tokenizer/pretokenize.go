@@ -0,0 +1,162 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The pre-tokenizers below are hand-written equivalents of the tiktoken split patterns,
+// which use lookahead and possessive quantifiers that Go's regexp does not support.
+// Each returns the length in runes of the piece at the start of text.
+
+// Contraction suffixes split off words, matched case-insensitively after an apostrophe
+var (
+	cl100kContractions = []string{"s", "d", "m", "t", "ll", "ve", "re"}
+	o200kContractions  = []string{"s", "t", "re", "ve", "m", "ll", "d"}
+)
+
+// nextCL100KPiece matches the cl100k_base pattern:
+//
+//	'(?i:[sdmt]|ll|ve|re)|[^\r\n\p{L}\p{N}]?+\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]++[\r\n]*|\s*[\r\n]|\s+(?!\S)|\s+
+func nextCL100KPiece(text []rune) int {
+	if n := matchContraction(text, cl100kContractions); n > 0 {
+		return n
+	}
+
+	// [^\r\n\p{L}\p{N}]?+\p{L}+
+	start := 0
+	if !isNewline(text[0]) && !unicode.IsLetter(text[0]) && !unicode.IsNumber(text[0]) {
+		start = 1
+	}
+	if n := countRunes(text[start:], unicode.IsLetter); n > 0 {
+		return start + n
+	}
+
+	if n := matchNumber(text); n > 0 {
+		return n
+	}
+	if n := matchPunctuation(text, isNewline); n > 0 {
+		return n
+	}
+	return matchWhitespace(text)
+}
+
+// nextO200KPiece matches the o200k_base pattern:
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?
+//	|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?
+//	|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func nextO200KPiece(text []rune) int {
+	for _, start := range prefixStarts(text) {
+		// Upper-case runs are greedy but give back runes a lower-case run can use
+		rest := text[start:]
+		for upper := countRunes(rest, isUpperLike); upper >= 0; upper-- {
+			if lower := countRunes(rest[upper:], isLowerLike); lower > 0 {
+				end := start + upper + lower
+				return end + matchContraction(text[end:], o200kContractions)
+			}
+		}
+	}
+
+	for _, start := range prefixStarts(text) {
+		if upper := countRunes(text[start:], isUpperLike); upper > 0 {
+			end := start + upper
+			end += countRunes(text[end:], isLowerLike)
+			return end + matchContraction(text[end:], o200kContractions)
+		}
+	}
+
+	if n := matchNumber(text); n > 0 {
+		return n
+	}
+	if n := matchPunctuation(text, func(r rune) bool { return isNewline(r) || r == '/' }); n > 0 {
+		return n
+	}
+	return matchWhitespace(text)
+}
+
+// prefixStarts returns the offsets a word may start at after the optional
+// [^\r\n\p{L}\p{N}]? prefix, in the order a regexp engine tries them
+func prefixStarts(text []rune) []int {
+	if !isNewline(text[0]) && !unicode.IsLetter(text[0]) && !unicode.IsNumber(text[0]) {
+		return []int{1, 0}
+	}
+	return []int{0}
+}
+
+// matchContraction matches an apostrophe followed by one of the contraction suffixes
+func matchContraction(text []rune, contractions []string) int {
+	if len(text) < 2 || text[0] != '\'' {
+		return 0
+	}
+	for _, contraction := range contractions {
+		n := len(contraction)
+		if len(text) > n && strings.EqualFold(string(text[1:1+n]), contraction) {
+			return 1 + n
+		}
+	}
+	return 0
+}
+
+// matchNumber matches \p{N}{1,3}
+func matchNumber(text []rune) int {
+	return min(countRunes(text, unicode.IsNumber), 3)
+}
+
+// matchPunctuation matches ` ?[^\s\p{L}\p{N}]+` followed by any runes accepted by trailing
+func matchPunctuation(text []rune, trailing func(rune) bool) int {
+	start := 0
+	if text[0] == ' ' {
+		start = 1
+	}
+	n := countRunes(text[start:], func(r rune) bool {
+		return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if n == 0 {
+		return 0
+	}
+	end := start + n
+	return end + countRunes(text[end:], trailing)
+}
+
+// matchWhitespace matches the whitespace alternatives shared by both patterns:
+// \s*[\r\n]+ (ending at the last line break of the run), \s+(?!\S) (leaving the
+// last space to the following word) and \s+
+func matchWhitespace(text []rune) int {
+	n := countRunes(text, unicode.IsSpace)
+	if n == 0 {
+		return 0
+	}
+	for i := n - 1; i >= 0; i-- {
+		if isNewline(text[i]) {
+			return i + 1
+		}
+	}
+	if n < len(text) && n > 1 {
+		return n - 1
+	}
+	return n
+}
+
+// countRunes returns the length of the run of runes at the start of text accepted by accept
+func countRunes(text []rune, accept func(rune) bool) int {
+	n := 0
+	for n < len(text) && accept(text[n]) {
+		n++
+	}
+	return n
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+// isUpperLike matches [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]
+func isUpperLike(r rune) bool {
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// isLowerLike matches [\p{Ll}\p{Lm}\p{Lo}\p{M}]
+func isLowerLike(r rune) bool {
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
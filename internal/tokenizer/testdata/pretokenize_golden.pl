@@ -0,0 +1,49 @@
+#!/usr/bin/env perl
+# Generates pretokenize_golden.json: the pieces the tiktoken split patterns cut sample texts into.
+# Perl's regex engine supports the Unicode classes, possessive quantifiers and lookahead of the
+# patterns, which are copied verbatim from tiktoken_ext/openai_public.py.
+#
+#   perl pretokenize_golden.pl > pretokenize_golden.json
+use strict;
+use warnings;
+use utf8;
+use JSON::PP;
+
+my %patterns = (
+    cl100k_base => qr/'(?i:[sdmt]|ll|ve|re)|[^\r\n\p{L}\p{N}]?+\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]++[\r\n]*|\s*[\r\n]|\s+(?!\S)|\s+/,
+    o200k_base  => qr/[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n\/]*|\s*[\r\n]+|\s+(?!\S)|\s+/,
+);
+
+my @texts = (
+    "Hello, world! It's a test of the tokenizer.",
+    "I'M SURE they'll say we've done it, DON'T you think? She'd agree.",
+    "HTTPServer's config: maxConnections=12345, timeout 3.14159s",
+    "Kubernetes (K8s) is an open-source system for automating deployment.",
+    "Привет, мир! Как дела? Это проверка токенизатора.",
+    "Под — это минимальная единица развёртывания в Kubernetes.",
+    "ОШИБКА: Превышена КВОТА 2024 года",
+    "func main() {\n\tfmt.Println(\"hi\")\n}\n",
+    "kubectl get pods -n kube-system --output=json | jq '.items[]'",
+    "path/to/file.go:42:7: undefined: foo\r\n",
+    "  leading spaces, trailing spaces   \n\n\nthree newlines",
+    "tabs\tand\t\tdouble tabs,   and    runs of spaces",
+    "Numbers: 1 22 333 4444 55555 666666 and 1,000,000.00",
+    "Café naïve résumé coöperate e\x{0301}cole",
+    "中文文本测试，日本語のテキスト。",
+    "emoji 🚀🔥 and symbols ©®™ and <html><body>x</body></html>",
+    "CamelCaseWord and snake_case_word and SCREAMING_SNAKE",
+    "a/b/c\n//comment\n/* block */",
+);
+
+my @cases;
+for my $encoding (sort keys %patterns) {
+    my $pattern = $patterns{$encoding};
+    for my $text (@texts) {
+        my @pieces = $text =~ /($pattern)/g;
+        die "pieces do not cover \"$text\"\n" if join('', @pieces) ne $text;
+        push @cases, { encoding => $encoding, text => $text, pieces => \@pieces };
+    }
+}
+
+binmode STDOUT, ':raw';
+print JSON::PP->new->utf8->canonical->indent->indent_length(2)->space_after->encode(\@cases);
@@ -0,0 +1,184 @@
+// Package tokenizer implements the byte pair encoding (BPE) used by OpenAI models, so that text
+// can be measured and split in the same tokens the embedding and chat models see.
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Supported encodings
+const (
+	// CL100KBase is the encoding of text-embedding-3-*, gpt-4 and gpt-3.5-turbo
+	CL100KBase = "cl100k_base"
+	// O200KBase is the encoding of gpt-4o and newer models
+	O200KBase = "o200k_base"
+)
+
+// ErrUnknownEncoding is returned for encoding names other than the supported ones
+var ErrUnknownEncoding = errors.New("unknown encoding")
+
+// Encoding is a BPE encoding: a pre-tokenizer splitting text into pieces and
+// merge ranks turning each piece into tokens. Special tokens such as <|endoftext|>
+// are not recognised and are encoded as ordinary text.
+type Encoding struct {
+	name    string
+	split   func(text []rune) int
+	ranks   map[string]int
+	decoder map[int]string
+}
+
+// NewEncoding creates an encoding from merge ranks keyed by token bytes.
+// The name selects the pre-tokenizer and must be one of the supported encodings.
+func NewEncoding(name string, ranks map[string]int) (*Encoding, error) {
+	var split func([]rune) int
+	switch name {
+	case CL100KBase:
+		split = nextCL100KPiece
+	case O200KBase:
+		split = nextO200KPiece
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEncoding, name)
+	}
+
+	decoder := make(map[int]string, len(ranks))
+	for token, rank := range ranks {
+		decoder[rank] = token
+	}
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("invalid %s ranks: byte 0x%02x has no token", name, b)
+		}
+	}
+
+	return &Encoding{name: name, split: split, ranks: ranks, decoder: decoder}, nil
+}
+
+// LoadEncoding reads merge ranks from a .tiktoken file, as published by OpenAI
+// (https://openaipublic.blob.core.windows.net/encodings/<name>.tiktoken), and creates an encoding.
+// Every line of the file holds a base64-encoded token and its rank.
+func LoadEncoding(name, path string) (*Encoding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ranks file: %w", err)
+	}
+	defer file.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		encoded, rankText, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid ranks file %s: line %d: expected token and rank", path, lineNumber)
+		}
+		token, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ranks file %s: line %d: %w", path, lineNumber, err)
+		}
+		rank, err := strconv.Atoi(rankText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ranks file %s: line %d: %w", path, lineNumber, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ranks file: %w", err)
+	}
+
+	return NewEncoding(name, ranks)
+}
+
+// Name returns the name of the encoding
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Split splits text into the pieces BPE is applied to. Tokens never cross piece
+// boundaries, and the pieces concatenate back to text.
+func (e *Encoding) Split(text string) []string {
+	runes := []rune(text)
+	var pieces []string
+	for len(runes) > 0 {
+		n := e.split(runes)
+		if n <= 0 {
+			n = 1
+		}
+		pieces = append(pieces, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return pieces
+}
+
+// Encode converts text into tokens
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range e.Split(text) {
+		tokens = append(tokens, e.encodePiece(piece)...)
+	}
+	return tokens
+}
+
+// Count returns the number of tokens in text
+func (e *Encoding) Count(text string) int {
+	count := 0
+	for _, piece := range e.Split(text) {
+		count += len(e.encodePiece(piece))
+	}
+	return count
+}
+
+// Decode converts tokens back into text. Unknown tokens are skipped. A token
+// sequence cut inside a multi-byte character decodes to invalid UTF-8.
+func (e *Encoding) Decode(tokens []int) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString(e.decoder[token])
+	}
+	return sb.String()
+}
+
+// encodePiece applies byte pair merges to a single piece: starting from single bytes,
+// the adjacent pair with the lowest rank is merged until no pair is a known token
+func (e *Encoding) encodePiece(piece string) []int {
+	if rank, ok := e.ranks[piece]; ok {
+		return []int{rank}
+	}
+
+	// bounds holds the start offsets of the current parts followed by len(piece)
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		bestRank, best := math.MaxInt, -1
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := e.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				bestRank, best = rank, i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	tokens := make([]int, 0, len(bounds)-1)
+	for i := 0; i+1 < len(bounds); i++ {
+		tokens = append(tokens, e.ranks[piece[bounds[i]:bounds[i+1]]])
+	}
+	return tokens
+}
@@ -0,0 +1,288 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// testRanks returns a small vocabulary: every byte as its own token plus a few merges
+// for English and Russian words
+func testRanks() map[string]int {
+	ranks := make(map[string]int)
+	for b := 0; b < 256; b++ {
+		ranks[string([]byte{byte(b)})] = b
+	}
+	for i, token := range []string{"he", " t", " the", "м", "и", "р", "ир", "мир", " мир"} {
+		ranks[token] = 256 + i
+	}
+	return ranks
+}
+
+func TestEncoding_Split(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		text     string
+		want     []string
+	}{
+		{
+			name:     "cl100k english",
+			encoding: CL100KBase,
+			text:     "Hello, world! It's 2024.",
+			want:     []string{"Hello", ",", " world", "!", " It", "'s", " ", "202", "4", "."},
+		},
+		{
+			name:     "cl100k russian",
+			encoding: CL100KBase,
+			text:     "Привет, мир!\n\nКак дела?",
+			want:     []string{"Привет", ",", " мир", "!\n\n", "Как", " дела", "?"},
+		},
+		{
+			name:     "cl100k whitespace",
+			encoding: CL100KBase,
+			text:     "x  = 1234567 \n  y",
+			want:     []string{"x", " ", " =", " ", "123", "456", "7", " \n", " ", " y"},
+		},
+		{
+			name:     "cl100k camel case",
+			encoding: CL100KBase,
+			text:     "helloWorld ПриветМир",
+			want:     []string{"helloWorld", " ПриветМир"},
+		},
+		{
+			name:     "o200k english",
+			encoding: O200KBase,
+			text:     "Hello, world! It's 2024.",
+			want:     []string{"Hello", ",", " world", "!", " It's", " ", "202", "4", "."},
+		},
+		{
+			name:     "o200k russian",
+			encoding: O200KBase,
+			text:     "Привет, мир!\n\nКак дела?",
+			want:     []string{"Привет", ",", " мир", "!\n\n", "Как", " дела", "?"},
+		},
+		{
+			name:     "o200k camel case",
+			encoding: O200KBase,
+			text:     "helloWorld HTTPServer ПриветМир",
+			want:     []string{"hello", "World", " HTTPServer", " Привет", "Мир"},
+		},
+		{
+			name:     "o200k upper case with contraction",
+			encoding: O200KBase,
+			text:     "DON'T STOP",
+			want:     []string{"DON'T", " STOP"},
+		},
+		{
+			name:     "empty text",
+			encoding: CL100KBase,
+			text:     "",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, err := NewEncoding(tt.encoding, testRanks())
+			if err != nil {
+				t.Fatalf("NewEncoding() error = %v", err)
+			}
+
+			got := encoding.Split(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+			if joined := strings.Join(got, ""); joined != tt.text {
+				t.Errorf("Split() pieces join to %q, want %q", joined, tt.text)
+			}
+		})
+	}
+}
+
+// TestEncoding_Split_Golden compares the pre-tokenizers with the pieces the tiktoken split
+// patterns produce, generated by testdata/pretokenize_golden.pl
+func TestEncoding_Split_Golden(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "pretokenize_golden.json"))
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	var cases []struct {
+		Encoding string   `json:"encoding"`
+		Text     string   `json:"text"`
+		Pieces   []string `json:"pieces"`
+	}
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatalf("failed to parse golden file: %v", err)
+	}
+
+	encodings := make(map[string]*Encoding)
+	for _, name := range []string{CL100KBase, O200KBase} {
+		encoding, err := NewEncoding(name, testRanks())
+		if err != nil {
+			t.Fatalf("NewEncoding(%s) error = %v", name, err)
+		}
+		encodings[name] = encoding
+	}
+
+	for _, tc := range cases {
+		encoding, ok := encodings[tc.Encoding]
+		if !ok {
+			t.Fatalf("golden case has unknown encoding %q", tc.Encoding)
+		}
+		if got := encoding.Split(tc.Text); !reflect.DeepEqual(got, tc.Pieces) {
+			t.Errorf("%s Split(%q) = %q, want %q", tc.Encoding, tc.Text, got, tc.Pieces)
+		}
+	}
+}
+
+func TestEncoding_Encode(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []int
+	}{
+		{
+			name: "merges by rank",
+			text: "the theme",
+			want: []int{'t', 256, 258, 'm', 'e'},
+		},
+		{
+			name: "whole piece is a token",
+			text: "Привет мир",
+			want: []int{0xd0, 0x9f, 261, 260, 0xd0, 0xb2, 0xd0, 0xb5, 0xd1, 0x82, 264},
+		},
+		{
+			name: "cyrillic merges with byte fallback",
+			text: "миры",
+			want: []int{263, 0xd1, 0x8b},
+		},
+		{
+			name: "empty text",
+			text: "",
+			want: nil,
+		},
+	}
+
+	encoding, err := NewEncoding(CL100KBase, testRanks())
+	if err != nil {
+		t.Fatalf("NewEncoding() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encoding.Encode(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encode() = %v, want %v", got, tt.want)
+			}
+			if count := encoding.Count(tt.text); count != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", count, len(tt.want))
+			}
+			if decoded := encoding.Decode(got); decoded != tt.text {
+				t.Errorf("Decode() = %q, want %q", decoded, tt.text)
+			}
+		})
+	}
+}
+
+func TestNewEncoding_Errors(t *testing.T) {
+	if _, err := NewEncoding("p50k_base", testRanks()); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("NewEncoding() error = %v, want ErrUnknownEncoding", err)
+	}
+
+	ranks := testRanks()
+	delete(ranks, "\x00")
+	if _, err := NewEncoding(CL100KBase, ranks); err == nil {
+		t.Error("NewEncoding() expected an error for ranks without every byte")
+	}
+}
+
+func TestLoadEncoding(t *testing.T) {
+	var sb strings.Builder
+	for token, rank := range testRanks() {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	path := filepath.Join(t.TempDir(), "cl100k_base.tiktoken")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatalf("failed to write ranks file: %v", err)
+	}
+
+	encoding, err := LoadEncoding(CL100KBase, path)
+	if err != nil {
+		t.Fatalf("LoadEncoding() error = %v", err)
+	}
+	if got, want := encoding.Encode("the theme"), []int{'t', 256, 258, 'm', 'e'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.tiktoken")
+	if err := os.WriteFile(invalidPath, []byte("dGhl\n"), 0o644); err != nil {
+		t.Fatalf("failed to write ranks file: %v", err)
+	}
+	if _, err := LoadEncoding(CL100KBase, invalidPath); err == nil {
+		t.Error("LoadEncoding() expected an error for a line without rank")
+	}
+
+	if _, err := LoadEncoding(CL100KBase, filepath.Join(t.TempDir(), "missing.tiktoken")); err == nil {
+		t.Error("LoadEncoding() expected an error for a missing file")
+	}
+}
+
+// TestLoadEncoding_Golden checks tokens of the published OpenAI ranks files against tiktoken.
+// It runs when TOKENIZER_PATH points at the .tiktoken file of TOKENIZER_ENCODING (cl100k_base by default).
+func TestLoadEncoding_Golden(t *testing.T) {
+	path := os.Getenv("TOKENIZER_PATH")
+	if path == "" {
+		t.Skip("TOKENIZER_PATH is not set")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Skipf("ranks file is not available: %v", err)
+	}
+	name := os.Getenv("TOKENIZER_ENCODING")
+	if name == "" {
+		name = CL100KBase
+	}
+
+	encoding, err := LoadEncoding(name, path)
+	if err != nil {
+		t.Fatalf("LoadEncoding() error = %v", err)
+	}
+
+	golden := map[string][]struct {
+		text string
+		want []int
+	}{
+		CL100KBase: {
+			{text: "hello world", want: []int{15339, 1917}},
+			{text: "Hello, world!", want: []int{9906, 11, 1917, 0}},
+			{text: "tiktoken is great!", want: []int{83, 1609, 5963, 374, 2294, 0}},
+		},
+		O200KBase: {
+			{text: "hello world", want: []int{24912, 2375}},
+			{text: "Hello, world!", want: []int{13225, 11, 2375, 0}},
+		},
+	}
+	for _, tt := range golden[name] {
+		if got := encoding.Encode(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	// Both vocabularies merge Cyrillic letters, so Russian text takes fewer tokens than letters
+	for _, text := range []string{"Привет, мир!", "Под — это минимальная единица развёртывания в Kubernetes."} {
+		tokens := encoding.Encode(text)
+		if got := encoding.Decode(tokens); got != text {
+			t.Errorf("Decode(Encode(%q)) = %q", text, got)
+		}
+		if letters := utf8.RuneCountInString(text); len(tokens) >= letters {
+			t.Errorf("Encode(%q) = %d tokens, want fewer than its %d characters", text, len(tokens), letters)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// QueryFilter restricts retrieval to chunks whose payload matches all given conditions
+type QueryFilter struct {
+	// DocIDs matches chunks of any of the listed documents
+	DocIDs []string `json:"doc_ids,omitempty"`
+	// Tags matches chunks tagged with any of the listed tags (metadata "tags")
+	Tags []string `json:"tags,omitempty"`
+	// Source matches chunks with exactly this source (metadata "source")
+	Source string `json:"source,omitempty"`
+	// IngestedAt matches chunks ingested within the range
+	IngestedAt *DateRange `json:"ingested_at,omitempty"`
+	// Dates matches chunks whose RFC 3339 metadata fields fall within the ranges, keyed by field name
+	Dates map[string]DateRange `json:"dates,omitempty"`
+}
+
+// DateRange is an inclusive time range; either bound may be omitted
+type DateRange struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
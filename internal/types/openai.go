@@ -0,0 +1,118 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChatCompletionRequest is a request in the OpenAI Chat Completions wire format.
+// Sampling parameters such as temperature are accepted and ignored.
+type ChatCompletionRequest struct {
+	Model         string                  `json:"model"`
+	Messages      []ChatCompletionMessage `json:"messages"`
+	Stream        bool                    `json:"stream,omitempty"`
+	StreamOptions *ChatStreamOptions      `json:"stream_options,omitempty"`
+	// Filter and KeywordWeight are extensions mirroring the /query request fields
+	Filter        *QueryFilter `json:"filter,omitempty"`
+	KeywordWeight *float64     `json:"keyword_weight,omitempty"`
+}
+
+// ChatStreamOptions controls streamed chat completions
+type ChatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ChatCompletionMessage is a chat message in the OpenAI wire format
+type ChatCompletionMessage struct {
+	Role    string             `json:"role"`
+	Content ChatMessageContent `json:"content"`
+}
+
+// ChatMessageContent is message content given either as a string or as an array of
+// content parts, of which only the text parts are kept
+type ChatMessageContent string
+
+// UnmarshalJSON accepts a string, an array of content parts or null
+func (c *ChatMessageContent) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = ChatMessageContent(text)
+		return nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("content must be a string or an array of content parts: %w", err)
+	}
+
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	*c = ChatMessageContent(strings.Join(texts, "\n"))
+	return nil
+}
+
+// ChatCompletionResponse is a chat completion in the OpenAI wire format
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   *TokenUsage            `json:"usage,omitempty"`
+	// Sources is an extension listing the retrieved chunks the answer was based on
+	Sources []ChatCompletionSource `json:"sources,omitempty"`
+}
+
+// ChatCompletionChoice is a single completion choice. Message is set in complete responses,
+// Delta in streamed chunks.
+type ChatCompletionChoice struct {
+	Index        int                  `json:"index"`
+	Message      *ChatCompletionDelta `json:"message,omitempty"`
+	Delta        *ChatCompletionDelta `json:"delta,omitempty"`
+	FinishReason *string              `json:"finish_reason"`
+}
+
+// ChatCompletionDelta is an assistant message or a streamed part of it
+type ChatCompletionDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionSource is a retrieved chunk together with its text
+type ChatCompletionSource struct {
+	Source
+	Text string `json:"text"`
+}
+
+// OpenAIErrorResponse is an error in the OpenAI wire format
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+// OpenAIError describes an OpenAI API error
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// ModelList lists the models served by the OpenAI-compatible API
+type ModelList struct {
+	Object string      `json:"object"`
+	Data   []ModelInfo `json:"data"`
+}
+
+// ModelInfo describes a model served by the OpenAI-compatible API
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
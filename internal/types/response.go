@@ -4,7 +4,72 @@ package types
 type QueryResponse struct {
 	Answer   string                 `json:"answer"`
 	Context  []string               `json:"context,omitempty"`
+	Sources  []Source               `json:"sources,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// InsufficientContext is set when no retrieved chunk was relevant enough
+	// and the answer was not generated by the LLM
+	InsufficientContext bool `json:"insufficient_context,omitempty"`
+	// ConversationID is the conversation the question and answer were added to
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// Source represents a retrieved chunk the answer was based on.
+// Sources are aligned with QueryResponse.Context, which holds the chunk texts.
+type Source struct {
+	DocID      string  `json:"doc_id,omitempty"`
+	ChunkIndex int     `json:"chunk_index"`
+	Score      float32 `json:"score"`
+	// HeadingPath is the chain of headings enclosing the chunk, e.g. "Pods > Lifecycle"
+	HeadingPath string `json:"heading_path,omitempty"`
+	// Offsets locates the chunk in the ingested document text, if the chunker recorded it
+	Offsets *TextRange `json:"offsets,omitempty"`
+	// FilePath, Symbol, StartLine and EndLine locate a source code chunk: the file, the
+	// declaration it holds and its 1-based line range
+	FilePath  string `json:"file_path,omitempty"`
+	Symbol    string `json:"symbol,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// TextRange is a range of character offsets in a text, end exclusive
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Answer represents a generated answer together with generation metadata
+type Answer struct {
+	Text  string
+	Model string
+	Usage TokenUsage
+}
+
+// TokenUsage represents LLM token consumption for a request
+type TokenUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// Document represents an ingested document stored in the vector database
+type Document struct {
+	ID          string          `json:"id"`
+	ChunkCount  int             `json:"chunk_count"`
+	IngestedAt  string          `json:"ingested_at,omitempty"`
+	ContentHash string          `json:"content_hash,omitempty"` // hex-encoded SHA-256 of the ingested text
+	Chunks      []DocumentChunk `json:"chunks,omitempty"`
+}
+
+// DocumentChunk represents a single stored chunk of a document
+type DocumentChunk struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+// DocumentListResponse represents a page of ingested documents
+type DocumentListResponse struct {
+	Documents  []Document `json:"documents"`
+	NextOffset string     `json:"next_offset,omitempty"`
 }
 
 // ErrorResponse represents an error response